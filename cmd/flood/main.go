@@ -5,8 +5,12 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -15,41 +19,105 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/margined-protocol/flood/internal/bot"
 	"github.com/margined-protocol/flood/internal/config"
+	"github.com/margined-protocol/flood/internal/endpoints"
+	"github.com/margined-protocol/flood/internal/events"
+	"github.com/margined-protocol/flood/internal/health"
 	"github.com/margined-protocol/flood/internal/liquidity"
 	"github.com/margined-protocol/flood/internal/logger"
-	"github.com/margined-protocol/flood/internal/maths"
+	"github.com/margined-protocol/flood/internal/metrics"
 	"github.com/margined-protocol/flood/internal/power"
 	"github.com/margined-protocol/flood/internal/queries"
+	"github.com/margined-protocol/flood/internal/transactions"
 	"github.com/margined-protocol/flood/internal/types"
 
 	"github.com/ignite/cli/ignite/pkg/cosmosaccount"
 	"github.com/ignite/cli/ignite/pkg/cosmosclient"
 
-	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
-	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	clquery "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/client/queryproto"
 	pmquery "github.com/osmosis-labs/osmosis/v21/x/poolmanager/client/queryproto"
+	txfeestypes "github.com/osmosis-labs/osmosis/v21/x/txfees/types"
 )
 
+const defaultHealthCheckInterval = 30 * time.Second
+
 var (
 	// version and buildDate is set with -ldflags in the Makefile
 	Version     string
 	BuildDate   string
 	configPath  *string
 	showVersion *bool
+	overrides   *config.Overrides
 )
 
 func parseFlags() {
-	configPath = flag.String("c", "config.toml", "path to config file")
+	defaultConfigPath := "config.toml"
+	if path, ok := os.LookupEnv(config.ConfigPathEnv); ok {
+		defaultConfigPath = path
+	}
+
+	configPath = flag.String("c", defaultConfigPath, "path to config file, also set by "+config.ConfigPathEnv+". Empty to configure from the environment and flags only")
 	showVersion = flag.Bool("v", false, "Print the version of the program")
+	// Every config key can be overridden, for example -dry-run or
+	// -power-pool.pool-id 1
+	overrides = config.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintln(out, "\nKeys of [[pools]] entries have no flags. They are overridden with\n"+
+			config.EnvPrefix+"POOLS_<index>_<key> environment variables, for entries already in the config file.")
+	}
 	flag.Parse()
 }
 
+// runCommand runs a subcommand given after the flags, such as
+// "config validate". It returns false if there is no subcommand.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	if len(args) < 2 || args[0] != "config" || args[1] != "validate" {
+		fmt.Fprintf(os.Stderr, "unknown command %q, available commands: config validate\n", strings.Join(args, " "))
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	path := fs.String("c", *configPath, "path to config file")
+	offline := fs.Bool("offline", false, "Skip checking that the endpoints are reachable")
+	_ = fs.Parse(args[2:])
+
+	if err := validateConfig(*path, *offline); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s is valid\n", *path)
+	return true
+}
+
+// validateConfig loads and validates the config and checks its endpoints
+func validateConfig(path string, offline bool) error {
+	cfg, err := config.LoadConfig(path, overrides)
+	if err != nil {
+		return err
+	}
+
+	if offline {
+		return nil
+	}
+
+	return config.CheckEndpoints(context.Background(), cfg)
+}
+
 // setup client initialises a cosmos client that maybe used to submit transactions
-func setupCosmosClient(ctx context.Context, cfg *types.Config) (*cosmosclient.Client, error) {
+func setupCosmosClient(ctx context.Context, cfg *types.Config, address string) (*cosmosclient.Client, error) {
 	opts := []cosmosclient.Option{
-		cosmosclient.WithNodeAddress(cfg.RPCServerAddress),
+		cosmosclient.WithNodeAddress(address),
 		cosmosclient.WithGas(cfg.Gas),
 		cosmosclient.WithGasAdjustment(cfg.GasAdjustment),
 		cosmosclient.WithAddressPrefix(cfg.AddressPrefix),
@@ -72,120 +140,134 @@ func setupGRPCConnection(address string) (*grpc.ClientConn, error) {
 	return grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 }
 
-// initialise performs the setup operations for the script
-// * initialise a logger
-// * load and parse config
-// * initialise a cosmosclient
-// * initilise a grpc connection
-func initialize(ctx context.Context, configPath string) (*zap.Logger, *types.Config, *cosmosclient.Client, *grpc.ClientConn) {
-	l, err := logger.Setup()
-	if err != nil {
-		log.Fatalf("Failed to initialize zap logger: %v", err)
-	}
+// setupClients returns a builder that wraps the numerous clients for an RPC
+// and a gRPC endpoint. Transactions go through the RPC endpoint and queries
+// through the gRPC endpoint.
+func setupClients(cfg *types.Config) endpoints.Builder {
+	return func(ctx context.Context, rpcAddress, grpcAddress string) (types.BlockchainClients, error) {
+		client, err := setupCosmosClient(ctx, cfg, rpcAddress)
+		if err != nil {
+			return types.BlockchainClients{}, err
+		}
 
-	cfg, err := config.LoadConfig(configPath)
-	if err != nil {
-		l.Fatal("Failed to load config", zap.Error(err))
-	}
+		conn, err := setupGRPCConnection(grpcAddress)
+		if err != nil {
+			return types.BlockchainClients{}, err
+		}
 
-	client, err := setupCosmosClient(ctx, cfg)
-	if err != nil {
-		l.Fatal("Failed to initialise cosmosclient", zap.Error(err))
+		return types.BlockchainClients{
+			CosmosClient: client,
+			GRPCClient:   conn,
+			WasmClient:   wasmtypes.NewQueryClient(conn),
+			PMClient:     pmquery.NewQueryClient(conn),
+			CLClient:     clquery.NewQueryClient(conn),
+			BankClient:   banktypes.NewQueryClient(conn),
+			TxFeesClient: txfeestypes.NewQueryClient(conn),
+			Config:       cfg,
+		}, nil
 	}
+}
 
-	conn, err := setupGRPCConnection(cfg.GRPCServerAddress)
-	if err != nil {
-		l.Fatal("Failed to connect to GRPC server", zap.Error(err))
+// reloadOnSignal reloads the config from path whenever a signal arrives on
+// sig until the context is done
+func reloadOnSignal(ctx context.Context, l *zap.Logger, sig <-chan os.Signal, path string, cfg *types.Config, workers *bot.Workers) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			cfg = reloadConfig(l, path, cfg, workers)
+		}
 	}
-
-	return l, cfg, client, conn
 }
 
-func handleEvent(l *zap.Logger, cfg *types.Config, ctx context.Context, address string, account cosmosaccount.Account, clients types.BlockchainClients, event ctypes.ResultEvent) {
+// reloadConfig loads the config and hands the new strategy parameters to the
+// bot of each pool. The reload is rejected if any key that requires a
+// restart changed. It returns the config in effect afterwards.
+func reloadConfig(l *zap.Logger, path string, current *types.Config, workers *bot.Workers) *types.Config {
+	l = l.With(zap.String("event", "config_reload"), zap.String("path", path))
 
-	// Get the power config and state
-	powerConfig, powerState, err := power.GetConfigAndState(ctx, clients.WasmClient, clients.Config.PowerPool.ContractAddress)
+	next, err := config.LoadConfig(path, overrides)
 	if err != nil {
-		l.Fatal("Failed to get config and state: %v", zap.Error(err))
+		l.Error("Config reload failed, keeping the current config", zap.String("result", "invalid"), zap.Error(err))
+		return current
 	}
 
-	// Get the spotprices for base and power
-	baseSpotPrice, powerSpotPrice, err := queries.GetSpotPrices(ctx, clients.PMClient, powerConfig)
-	if err != nil {
-		l.Fatal("Failed to fetch spot prices", zap.Error(err))
+	changes := config.Diff(current, next)
+	if len(changes) == 0 {
+		l.Info("Config reloaded, nothing changed", zap.String("result", "unchanged"))
+		return current
 	}
 
-	// Calculate the mark price
-	markPrice, err := maths.CalculateMarkPrice(baseSpotPrice, powerSpotPrice, powerState.NormalisationFactor, powerConfig.IndexScale)
-	if err != nil {
-		l.Fatal("Failed to calculate mark price", zap.Error(err))
+	var rejected []string
+	for _, change := range changes {
+		if !config.Reloadable(change.Key) {
+			rejected = append(rejected, change.Key)
+		}
 	}
 
-	// Calcuate the index price
-	indexPrice, err := maths.CalculateIndexPrice(baseSpotPrice)
-	if err != nil {
-		l.Fatal("Failed to calculate index price", zap.Error(err))
+	if len(rejected) > 0 {
+		l.Error("Config reload rejected, only position keys can be reloaded and the other changes need a restart",
+			zap.String("result", "rejected"),
+			zap.Strings("keys", rejected),
+		)
+		return current
 	}
 
-	// Calculate the target price
-	targetPrice, err := maths.CalculateTargetPrice(baseSpotPrice, powerState.NormalisationFactor, powerConfig.IndexScale)
-	if err != nil {
-		l.Fatal("Failed to calculate target price", zap.Error(err))
+	// Pools cannot be added or removed by a reload, so the pools are in
+	// the same order as the bots
+	var cfgs []*types.Config
+	for _, pool := range config.Pools(next) {
+		cfgs = append(cfgs, config.ForPool(next, pool))
 	}
 
-	// Calculate the premium
-	premium := maths.CalculatePremium(markPrice, indexPrice)
-
-	// get inverse target and spot prices
-	floatPowerSpotPrice, err := strconv.ParseFloat(powerSpotPrice, 64)
-	if err != nil {
-		l.Fatal("Failed to parse power spot price", zap.Error(err))
+	if err := workers.Reload(cfgs); err != nil {
+		l.Error("Config reload failed, keeping the current config", zap.String("result", "invalid"), zap.Error(err))
+		return current
 	}
 
-	inverseTargetPrice := 1 / targetPrice
-	inversePowerPrice := 1 / floatPowerSpotPrice
+	l.Info("Config reloaded", zap.String("result", "applied"), zap.Any("changes", changes))
 
-	// Now lets check if we have any open CL positions for the bot
-	userPositions, err := queries.GetUserPositions(ctx, clients.CLClient, powerConfig.PowerPool, address)
+	return next
+}
+
+// initialise performs the setup operations for the script
+// * initialise a logger
+// * load and parse config
+// * health check the rpc and grpc endpoints
+// * initialise the clients on the best endpoints
+func initialize(ctx context.Context, configPath string) (*zap.Logger, *types.Config, *endpoints.Pool, *endpoints.Clients) {
+	l, err := logger.Setup()
 	if err != nil {
-		l.Fatal("Failed to find user positions", zap.Error(err))
+		log.Fatalf("Failed to initialize zap logger: %v", err)
 	}
 
-	currentTick, err := queries.GetCurrentTick(ctx, clients.PMClient, powerConfig.PowerPool.ID)
+	cfg, err := config.LoadConfig(configPath, overrides)
 	if err != nil {
-		l.Fatal("Failed to get current tick", zap.Error(err))
-	}
-
-	// Sanity check computations
-	l.Debug("Summary data",
-		zap.Float64("mark_price", markPrice),
-		zap.Float64("target_price", targetPrice),
-		zap.Float64("inverse_target_price", inverseTargetPrice),
-		zap.String("power_price", powerSpotPrice),
-		zap.Float64("inverse_power_price", inversePowerPrice),
-		zap.Float64("premium", premium),
-		zap.String("normalization_factor", powerState.NormalisationFactor),
-		zap.Int64("current_tick", currentTick),
-	)
+		l.Fatal("Failed to load config", zap.Error(err))
+	}
 
-	powerPriceStr := fmt.Sprintf("%f", inversePowerPrice)
-	targetPriceStr := fmt.Sprintf("%f", inverseTargetPrice)
+	rpcPool := endpoints.NewPool(l, "rpc", config.RPCAddresses(cfg), cfg.MaxHeightLag, cfg.MaxLatency, endpoints.RPCChecker)
+	grpcPool := endpoints.NewPool(l, "grpc", config.GRPCAddresses(cfg), cfg.MaxHeightLag, cfg.MaxLatency, endpoints.GRPCChecker)
 
-	msgs, err := liquidity.CreateUpdatePositionMsgs(l, *userPositions, cfg, currentTick, address, powerPriceStr, targetPriceStr)
-	if err != nil {
-		l.Fatal("Failed to create update position msgs", zap.Error(err))
+	rpcPool.Check(ctx)
+	grpcPool.Check(ctx)
+
+	interval := cfg.HealthCheckInterval
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
 	}
 
-	txResp, err := clients.CosmosClient.BroadcastTx(ctx, account, msgs...)
-	if err != nil {
-		l.Error("Transaction error",
-			zap.Error(err),
-		)
-	} else {
-		l.Debug("tx response",
-			zap.String("transaction hash", txResp.TxHash),
-		)
+	go rpcPool.Run(ctx, interval)
+	go grpcPool.Run(ctx, interval)
+
+	clients := endpoints.NewClients(l, rpcPool, grpcPool, setupClients(cfg))
+
+	if _, err := clients.Get(ctx); err != nil {
+		l.Fatal("Failed to initialise clients", zap.Error(err))
 	}
+
+	return l, cfg, rpcPool, clients
 }
 
 func main() {
@@ -195,14 +277,29 @@ func main() {
 		os.Exit(0)
 	}
 
-	ctx := context.Background()
+	if runCommand(flag.Args()) {
+		os.Exit(0)
+	}
 
-	// Intialise logger, config, comsosclient and grpc client
-	l, cfg, client, conn := initialize(ctx, *configPath)
-	defer conn.Close()
+	// Cancel the root context on SIGINT or SIGTERM so that the bot can shut
+	// down cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Intialise logger, config, endpoints and clients
+	l, cfg, rpcPool, clientSet := initialize(ctx, *configPath)
+
+	if cfg.DryRun {
+		l.Info("Dry run, transactions are simulated and never broadcast")
+	}
+
+	clients, err := clientSet.Get(ctx)
+	if err != nil {
+		l.Fatal("Failed to get clients", zap.Error(err))
+	}
 
 	// Get the client account
-	account, err := client.Account(cfg.SignerAccount)
+	account, err := clients.CosmosClient.Account(cfg.SignerAccount)
 	if err != nil {
 		l.Fatal("Error fetching signer account",
 			zap.Error(err),
@@ -218,64 +315,158 @@ func main() {
 		)
 	}
 
-	// Initialise a wasm query client to read state from power contract
-	//nolint:staticcheck
-	c := wasmtypes.NewQueryClient(client.Context())
+	m := metrics.New()
 
-	// Initialise a poolmanager query client
-	//nolint:staticcheck
-	pmClient := pmquery.NewQueryClient(client.Context())
+	// All pools share the signer, so they share a broadcaster that keeps
+	// track of its account sequence
+	broadcaster := transactions.NewBroadcaster(l, cfg, account, "")
 
-	// Initialise a concentrated liquidity query client
-	//nolint:staticcheck
-	clClient := clquery.NewQueryClient(client.Context())
+	// Give every pool its own bot and subscribe to the swaps in it and in
+	// the pools that trigger its rebalances
+	workers := bot.NewWorkers(l, cfg.CoalesceWindow)
+	var queries []string
+
+	for _, pool := range config.Pools(cfg) {
+		b, poolQueries := setupPool(ctx, l, config.ForPool(cfg, pool), clientSet, clients, broadcaster, m, address)
+		workers.Add(b, poolQueries...)
+		queries = events.MergeQueries(queries, poolQueries)
+	}
+
+	// Keep the websocket subscriptions alive, reconnecting when they drop or
+	// go stale
+	supervisor := events.NewSupervisor(l, rpcPool.Best, cfg.WebsocketPath, queries, cfg.MaxSubscriptions, cfg.WebsocketStaleTimeout, cfg.ReconnectMaxBackoff, m)
+	supervisor.RebalanceEvery(cfg.RebalanceInterval, cfg.RebalanceBlocks)
+	supervisorDone := make(chan struct{})
+	go func() {
+		defer close(supervisorDone)
+		supervisor.Run(ctx)
+	}()
+
+	// Reload the strategy parameters on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go reloadOnSignal(ctx, l, hup, *configPath, cfg, workers)
+
+	// Serve metrics and the health endpoints
+	if cfg.HTTPAddress != "" {
+		checker := health.NewChecker(l, cfg.HealthStaleTimeout, supervisor, workers)
+		checker.AddCheck("grpc", health.GRPCCheck(clientSet))
+
+		for _, pool := range config.Pools(cfg) {
+			checker.AddCheck(fmt.Sprintf("power_paused.%d", pool.PoolId), health.PausedCheck(clientSet, pool.ContractAddress))
+		}
+
+		if cfg.MinSignerBalance != "" {
+			minBalance, err := sdk.ParseCoinNormalized(cfg.MinSignerBalance)
+			if err != nil {
+				l.Fatal("Failed to parse min signer balance", zap.Error(err))
+			}
+			checker.AddCheck("signer_balance", health.BalanceCheck(clientSet, address, minBalance))
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+		checker.Register(mux)
+
+		go metrics.Serve(ctx, l, cfg.HTTPAddress, mux)
+	}
 
-	// Initialise a websocket client
-	wsClient, err := rpchttp.New(cfg.RPCServerAddress, cfg.WebsocketPath)
+	// Handle events until a shutdown signal arrives or rebalancing of a pool
+	// keeps failing
+	runErr := workers.Run(ctx, supervisor.Events())
+
+	l.Info("Shutting down")
+
+	// Stop the subscription and wait for it to unsubscribe
+	stop()
+	<-supervisorDone
+
+	if err := workers.Shutdown(); err != nil {
+		l.Error("Failed to withdraw positions on shutdown", zap.Error(err))
+	}
+
+	clientSet.Close()
+
+	if runErr != nil {
+		l.Fatal("Stopping after repeated failures", zap.Error(runErr))
+	}
+
+	l.Info("Shutdown complete")
+}
+
+// setupPool checks the pool of a pool config against chain and returns the
+// bot for it together with the queries for swaps in the pool and its trigger
+// pools
+func setupPool(ctx context.Context, l *zap.Logger, cfg *types.Config, clientSet *endpoints.Clients, clients types.BlockchainClients, broadcaster *transactions.Broadcaster, m *metrics.Metrics, address string) (*bot.Bot, []string) {
+	l = l.With(zap.Uint64("pool_id", cfg.PowerPool.PoolId))
+
+	// Check the configured assets against the pool on chain
+	pool, err := queries.GetConcentratedPool(ctx, clients.PMClient, cfg.PowerPool.PoolId)
 	if err != nil {
-		l.Fatal("Error subscribing to websocket client", zap.Error(err))
+		l.Fatal("Failed to get concentrated pool", zap.Error(err))
 	}
 
-	err = wsClient.Start()
+	err = liquidity.ValidatePoolAssets(pool, cfg.PowerPool.BaseAsset, cfg.PowerPool.QuoteAsset)
 	if err != nil {
-		l.Fatal("Error starting websocket client",
-			zap.Error(err),
+		l.Fatal("Pool does not match config", zap.Error(err))
+	}
+
+	// Rebalances trade the power pool of the contract, so it must be the
+	// pool that is validated and subscribed to
+	powerConfig, _, err := power.GetConfigAndState(ctx, clients.WasmClient, cfg.PowerPool.ContractAddress)
+	if err != nil {
+		l.Fatal("Failed to get power config", zap.Error(err))
+	}
+
+	if powerConfig.PowerPool.ID != cfg.PowerPool.PoolId {
+		l.Fatal("Power contract trades a different pool than pool_id",
+			zap.Uint64("contract_pool_id", powerConfig.PowerPool.ID),
+			zap.String("contract_address", cfg.PowerPool.ContractAddress),
 		)
 	}
 
-	// Generate the query we are listening for, in this case tokens swapped in a pool
-	query := fmt.Sprintf("token_swapped.module = 'gamm' AND token_swapped.pool_id = '%d'", cfg.PowerPool.PoolId)
-	// query := "token_swapped.module = 'gamm'"
+	l.Info("Loaded concentrated pool",
+		zap.String("token0", pool.Token0),
+		zap.String("token1", pool.Token1),
+		zap.Int64("tick_spacing", pool.TickSpacing),
+		zap.String("spread_factor", pool.SpreadFactor),
+	)
 
-	// An arbitraty string to identify the subscription needed for the client
-	subscriber := "gobot"
+	// Build the strategy that decides where liquidity is placed
+	strategy, err := liquidity.NewStrategy(cfg.Strategy, cfg)
+	if err != nil {
+		l.Fatal("Failed to initialise strategy", zap.Error(err))
+	}
 
-	//nolint:staticcheck
-	eventCh, err := wsClient.Subscribe(ctx, subscriber, query)
+	// Minimum amounts on new positions protect against price moves between
+	// the withdraw and create messages
+	slippage, err := liquidity.ParseSlippageTolerance(cfg.Position.SlippageTolerance)
 	if err != nil {
-		l.Fatal("Error subscribing websocket client",
-			zap.Error(err),
-		)
+		l.Fatal("Failed to parse slippage tolerance", zap.Error(err))
 	}
 
-	// Wrap the numerous clients for convenience
-	clients := types.BlockchainClients{
-		CosmosClient:    client,
-		WebsocketClient: wsClient,
-		WasmClient:      c,
-		PMClient:        pmClient,
-		CLClient:        clClient,
-		Config:          cfg,
+	// Tag transactions with the memo so that they can be found on chain
+	memo, err := transactions.RenderMemo(cfg.Memo, transactions.MemoData{
+		Version:  Version,
+		Strategy: strategy.Name(),
+		PoolID:   cfg.PowerPool.PoolId,
+	})
+	if err != nil {
+		l.Fatal("Failed to render memo", zap.Error(err))
 	}
 
-	go func() {
-		for {
-			event := <-eventCh
-			handleEvent(l, cfg, ctx, address, account, clients, event)
-		}
-	}()
+	b := bot.New(l, cfg, clientSet, broadcaster.WithMemo(memo), m, address, strategy, slippage)
+
+	// Generate the queries we are listening for, tokens swapped in the pool
+	// or in a pool that moves its base price
+	poolQueries, err := events.SwapQueries(cfg.EventQueries, append([]uint64{cfg.PowerPool.PoolId}, cfg.TriggerPools...)...)
+	if err != nil {
+		l.Fatal("Failed to render event queries", zap.Error(err))
+	}
 
-	// Keep the main goroutine running
-	select {}
+	l.Info("Subscribing to events", zap.Strings("queries", poolQueries))
 
+	return b, poolQueries
 }
@@ -0,0 +1,753 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/osmosis-labs/osmosis/osmomath"
+	model "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/model"
+	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/endpoints"
+	"github.com/margined-protocol/flood/internal/events"
+	"github.com/margined-protocol/flood/internal/liquidity"
+	"github.com/margined-protocol/flood/internal/maths"
+	"github.com/margined-protocol/flood/internal/metrics"
+	"github.com/margined-protocol/flood/internal/power"
+	"github.com/margined-protocol/flood/internal/queries"
+	"github.com/margined-protocol/flood/internal/retry"
+	"github.com/margined-protocol/flood/internal/transactions"
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+const (
+	defaultMaxConsecutiveFailures = 5
+	defaultRetryAttempts          = 3
+	defaultRetryBackoff           = time.Second
+	maxRetryBackoff               = 30 * time.Second
+	defaultShutdownTimeout        = 30 * time.Second
+	defaultConfirmationTimeout    = time.Minute
+)
+
+// Bot rebalances the liquidity of the power pool in response to events
+type Bot struct {
+	l        *zap.Logger
+	cfg      *types.Config
+	clients  *endpoints.Clients
+	address  string
+	strategy liquidity.Strategy
+	slippage osmomath.Dec
+	gate     *liquidity.PremiumGate
+
+	broadcaster *transactions.Broadcaster
+
+	retryPolicy            retry.Policy
+	maxConsecutiveFailures int
+	consecutiveFailures    int
+	shutdownTimeout        time.Duration
+	confirmationTimeout    time.Duration
+
+	// positions holds the bot's positions by id, as last queried and
+	// updated by the events of confirmed transactions
+	positions map[uint64]position
+
+	// lastRebalance holds the unix nano time of the last successful
+	// rebalance
+	lastRebalance atomic.Int64
+
+	// reloads holds reloaded strategy parameters until they are applied
+	// between events
+	reloads chan reload
+
+	metrics *metrics.Metrics
+}
+
+// reload is a reloaded config and the strategy built from it
+type reload struct {
+	cfg      *types.Config
+	strategy liquidity.Strategy
+	slippage osmomath.Dec
+}
+
+// position is the liquidity and token amounts of a position
+type position struct {
+	liquidity osmomath.Dec
+	amount0   sdkmath.Int
+	amount1   sdkmath.Int
+}
+
+// New returns a bot that manages the positions of address, sending
+// transactions through the broadcaster
+func New(l *zap.Logger, cfg *types.Config, clients *endpoints.Clients, broadcaster *transactions.Broadcaster, m *metrics.Metrics, address string, strategy liquidity.Strategy, slippage osmomath.Dec) *Bot {
+	maxConsecutiveFailures := cfg.MaxConsecutiveFailures
+	if maxConsecutiveFailures <= 0 {
+		maxConsecutiveFailures = defaultMaxConsecutiveFailures
+	}
+
+	retryAttempts := cfg.RetryAttempts
+	if retryAttempts <= 0 {
+		retryAttempts = defaultRetryAttempts
+	}
+
+	retryBackoff := cfg.RetryBackoff
+	if retryBackoff <= 0 {
+		retryBackoff = defaultRetryBackoff
+	}
+
+	shutdownTimeout := cfg.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	confirmationTimeout := cfg.ConfirmationTimeout
+	if confirmationTimeout <= 0 {
+		confirmationTimeout = defaultConfirmationTimeout
+	}
+
+	return &Bot{
+		l:           l,
+		cfg:         cfg,
+		clients:     clients,
+		address:     address,
+		strategy:    strategy,
+		slippage:    slippage,
+		gate:        liquidity.NewPremiumGate(cfg.Position.PremiumThreshold, cfg.Position.PremiumHysteresis),
+		broadcaster: broadcaster,
+		retryPolicy: retry.Policy{
+			Attempts:       retryAttempts,
+			InitialBackoff: retryBackoff,
+			MaxBackoff:     maxRetryBackoff,
+		},
+		maxConsecutiveFailures: maxConsecutiveFailures,
+		shutdownTimeout:        shutdownTimeout,
+		confirmationTimeout:    confirmationTimeout,
+		positions:              make(map[uint64]position),
+		reloads:                make(chan reload, 1),
+		metrics:                m,
+	}
+}
+
+// Run handles events until the channel is closed or the context is done. An
+// event that is being handled when the context is done is drained rather than
+// abandoned. Run returns an error once rebalancing has failed for the
+// configured number of consecutive events. Reloaded strategy parameters are
+// applied between events.
+func (b *Bot) Run(ctx context.Context, eventCh <-chan events.Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case r := <-b.reloads:
+			b.applyReload(r)
+		case event, ok := <-eventCh:
+			if !ok {
+				return nil
+			}
+			if err := b.HandleEvent(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// newReload builds the strategy and slippage tolerance of a reloaded config.
+// Only the position parameters of the config are expected to have changed.
+func newReload(cfg *types.Config) (reload, error) {
+	strategy, err := liquidity.NewStrategy(cfg.Strategy, cfg)
+	if err != nil {
+		return reload{}, err
+	}
+
+	slippage, err := liquidity.ParseSlippageTolerance(cfg.Position.SlippageTolerance)
+	if err != nil {
+		return reload{}, err
+	}
+
+	return reload{cfg: cfg, strategy: strategy, slippage: slippage}, nil
+}
+
+// queueReload queues a reload to be swapped in before the next event,
+// replacing any reload still queued
+func (b *Bot) queueReload(r reload) {
+	for {
+		select {
+		case b.reloads <- r:
+			return
+		default:
+			select {
+			case <-b.reloads:
+			default:
+			}
+		}
+	}
+}
+
+// applyReload swaps in reloaded strategy parameters. The premium gate is
+// only rebuilt when its parameters changed so that its state is kept.
+func (b *Bot) applyReload(r reload) {
+	if r.cfg.Position.PremiumThreshold != b.cfg.Position.PremiumThreshold ||
+		r.cfg.Position.PremiumHysteresis != b.cfg.Position.PremiumHysteresis {
+		b.gate = liquidity.NewPremiumGate(r.cfg.Position.PremiumThreshold, r.cfg.Position.PremiumHysteresis)
+	}
+
+	b.cfg = r.cfg
+	b.strategy = r.strategy
+	b.slippage = r.slippage
+
+	b.l.Info("Applied reloaded strategy parameters",
+		zap.String("event", "config_reload_applied"),
+		zap.String("strategy", b.strategy.Name()),
+	)
+}
+
+// HandleEvent rebalances for an event, retrying transient failures. Failures
+// are logged and counted, and an error is only returned once the number of
+// consecutive failures reaches the limit.
+//
+// Once the context is done no further attempts are made, but the current
+// attempt is given up to the shutdown timeout to finish so that a transaction
+// is not abandoned halfway.
+func (b *Bot) HandleEvent(ctx context.Context, event events.Event) error {
+	switch event.Result.Query {
+	case events.CatchUpQuery:
+		b.l.Info("Reconnected, reconciling positions")
+	case events.TimerQuery, events.BlockIntervalQuery:
+		b.l.Info("Periodic rebalance", zap.String("trigger", event.Result.Query))
+	}
+
+	b.metrics.Trigger(b.PoolID(), event.Count)
+	b.l.Debug("Rebalancing",
+		zap.Int64("height", event.Height),
+		zap.Int("events", event.Count),
+	)
+
+	drainCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	stop := context.AfterFunc(ctx, func() {
+		b.l.Info("Shutting down, draining in-flight rebalance", zap.Duration("timeout", b.shutdownTimeout))
+		time.AfterFunc(b.shutdownTimeout, cancel)
+	})
+	defer stop()
+
+	err := retry.Do(ctx, b.retryPolicy, func() error {
+		err := b.rebalance(drainCtx, event)
+		// Fail over from an endpoint that could not be reached before
+		// the next attempt
+		b.clients.Report(err)
+		return err
+	}, func(attempt int, err error, backoff time.Duration) {
+		b.l.Warn("Rebalance failed, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+	})
+	if err == nil {
+		b.consecutiveFailures = 0
+		b.lastRebalance.Store(time.Now().UnixNano())
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	b.consecutiveFailures++
+	b.metrics.Rebalance(b.cfg.PowerPool.PoolId, metrics.RebalanceFailed)
+
+	b.l.Error("Rebalance failed",
+		zap.Bool("retryable", retry.IsRetryable(err)),
+		zap.Int("consecutive_failures", b.consecutiveFailures),
+		zap.Int("max_consecutive_failures", b.maxConsecutiveFailures),
+		zap.Error(err),
+	)
+
+	if b.consecutiveFailures >= b.maxConsecutiveFailures {
+		return fmt.Errorf("%d consecutive rebalances failed: %w", b.consecutiveFailures, err)
+	}
+
+	return nil
+}
+
+// PoolID returns the id of the pool the bot manages
+func (b *Bot) PoolID() uint64 {
+	return b.cfg.PowerPool.PoolId
+}
+
+// LastRebalance returns the time of the last successful rebalance, or the
+// zero time
+func (b *Bot) LastRebalance() time.Time {
+	n := b.lastRebalance.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// rebalance reads the market state and moves the positions if needed
+func (b *Bot) rebalance(ctx context.Context, event events.Event) error {
+	l := b.l
+	poolID := b.cfg.PowerPool.PoolId
+
+	clients, err := b.clients.Get(ctx)
+	if err != nil {
+		return retry.Retryable(fmt.Errorf("getting clients: %w", err))
+	}
+
+	// Pools sharing the signer and a denom take turns to plan and submit, so
+	// that each plans against the balance the other has not reserved
+	funds := b.broadcaster.Funds()
+	unlock := sync.OnceFunc(funds.Lock(b.cfg.PowerPool.BaseAsset, b.cfg.PowerPool.QuoteAsset))
+	defer unlock()
+
+	// Read every input to the strategy at the same block height
+	height, err := b.snapshotHeight(ctx, clients, event)
+	if err != nil {
+		return err
+	}
+
+	l = l.With(zap.Int64("height", height))
+	queryCtx := queries.AtHeight(ctx, height)
+
+	// Get the power config and state
+	powerConfig, powerState, err := power.GetConfigAndState(queryCtx, clients.WasmClient, b.cfg.PowerPool.ContractAddress)
+	if err != nil {
+		return fmt.Errorf("getting power config and state: %w", err)
+	}
+
+	// Get the spotprices for base and power
+	baseSpotPrice, powerSpotPrice, err := queries.GetSpotPrices(queryCtx, clients.PMClient, powerConfig)
+	if err != nil {
+		return fmt.Errorf("fetching spot prices: %w", err)
+	}
+
+	// Calculate the mark price
+	markPrice, err := maths.CalculateMarkPrice(baseSpotPrice, powerSpotPrice, powerState.NormalisationFactor, powerConfig.IndexScale)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("calculating mark price: %w", err))
+	}
+
+	// Calcuate the index price
+	indexPrice, err := maths.CalculateIndexPrice(baseSpotPrice)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("calculating index price: %w", err))
+	}
+
+	// Calculate the target price
+	targetPrice, err := maths.CalculateTargetPrice(baseSpotPrice, powerState.NormalisationFactor, powerConfig.IndexScale)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("calculating target price: %w", err))
+	}
+
+	// Calculate the premium
+	premium := maths.CalculatePremium(markPrice, indexPrice)
+
+	// get inverse target and spot prices
+	floatPowerSpotPrice, err := strconv.ParseFloat(powerSpotPrice, 64)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("parsing power spot price: %w", err))
+	}
+
+	inverseTargetPrice := 1 / targetPrice
+	inversePowerPrice := 1 / floatPowerSpotPrice
+
+	// Now lets check if we have any open CL positions for the bot
+	userPositions, err := queries.GetUserPositions(queryCtx, clients.CLClient, powerConfig.PowerPool, b.address)
+	if err != nil {
+		return fmt.Errorf("finding user positions: %w", err)
+	}
+
+	b.setPositions(poolID, userPositions.Positions)
+
+	pool, err := queries.GetConcentratedPool(queryCtx, clients.PMClient, powerConfig.PowerPool.ID)
+	if err != nil {
+		return fmt.Errorf("getting concentrated pool: %w", err)
+	}
+
+	balances, err := queries.GetBalances(queryCtx, clients.BankClient, b.address)
+	if err != nil {
+		return fmt.Errorf("getting balances: %w", err)
+	}
+	balances = funds.Available(balances)
+
+	// Sanity check computations
+	l.Debug("Summary data",
+		zap.Float64("mark_price", markPrice),
+		zap.Float64("target_price", targetPrice),
+		zap.Float64("inverse_target_price", inverseTargetPrice),
+		zap.String("power_price", powerSpotPrice),
+		zap.Float64("inverse_power_price", inversePowerPrice),
+		zap.Float64("premium", premium),
+		zap.String("normalization_factor", powerState.NormalisationFactor),
+		zap.Int64("current_tick", pool.CurrentTick),
+	)
+
+	normalisationFactor, err := strconv.ParseFloat(powerState.NormalisationFactor, 64)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("parsing normalisation factor: %w", err))
+	}
+
+	b.metrics.SetMarket(poolID, metrics.Market{
+		MarkPrice:           markPrice,
+		TargetPrice:         targetPrice,
+		IndexPrice:          indexPrice,
+		Premium:             premium,
+		NormalisationFactor: normalisationFactor,
+		CurrentTick:         pool.CurrentTick,
+	})
+
+	// Only reposition once the premium has moved outside of the threshold band
+	if !b.shouldRebalance(event, premium, len(userPositions.Positions) > 0) {
+		l.Info("Premium within threshold, skipping rebalance",
+			zap.Float64("premium", premium),
+			zap.Float64("premium_threshold", b.cfg.Position.PremiumThreshold),
+			zap.Float64("premium_hysteresis", b.cfg.Position.PremiumHysteresis),
+		)
+		b.metrics.Rebalance(poolID, metrics.RebalanceSkipped)
+		return nil
+	}
+
+	snapshot := liquidity.MarketSnapshot{
+		Height:              height,
+		Pool:                pool,
+		BaseSpotPrice:       baseSpotPrice,
+		PowerSpotPrice:      powerSpotPrice,
+		SpotPrice:           fmt.Sprintf("%f", inversePowerPrice),
+		TargetPrice:         fmt.Sprintf("%f", inverseTargetPrice),
+		NormalisationFactor: powerState.NormalisationFactor,
+		CurrentTick:         pool.CurrentTick,
+		Positions:           userPositions.Positions,
+		Balances:            balances,
+	}
+
+	msgs, spend, err := liquidity.CreateUpdatePositionMsgs(l, b.strategy, snapshot, b.address, b.slippage)
+	if errors.Is(err, liquidity.ErrNothingToDeploy) {
+		l.Info("Nothing to deploy, skipping rebalance", zap.Error(err))
+		b.metrics.Rebalance(poolID, metrics.RebalanceSkipped)
+		return nil
+	}
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("creating update position msgs: %w", err))
+	}
+
+	if len(msgs) == 0 {
+		l.Info("Positions already match the strategy")
+		b.gate.Record(premium)
+		b.metrics.Rebalance(poolID, metrics.RebalanceUnchanged)
+		return nil
+	}
+
+	if b.cfg.DryRun {
+		if err := b.simulate(ctx, clients, pool, msgs); err != nil {
+			return fmt.Errorf("simulating transaction: %w", err)
+		}
+		b.gate.Record(premium)
+		b.metrics.Rebalance(poolID, metrics.RebalanceSimulated)
+		return nil
+	}
+
+	// The funds stay reserved until the transaction has confirmed, so other
+	// pools need not wait for the confirmation to plan
+	release := funds.Reserve(spend)
+	resp, err := b.submit(ctx, clients, msgs, event.ReceivedAt)
+	unlock()
+	defer release()
+
+	// Broadcasting again could submit the same messages twice, so
+	// transaction errors are not retried. The next event rebalances from the
+	// state the transaction left behind.
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	if _, err := b.confirm(ctx, clients, resp); err != nil {
+		return retry.Permanent(err)
+	}
+
+	b.gate.Record(premium)
+	b.metrics.Rebalance(poolID, metrics.RebalanceSubmitted)
+
+	return nil
+}
+
+// shouldRebalance reports whether the positions should be moved for the
+// premium. Periodic events bypass the premium gate, so that positions follow
+// the target price of a quiet pool whose premium stays within the band.
+func (b *Bot) shouldRebalance(event events.Event, premium float64, hasPositions bool) bool {
+	// The gate is always consulted so that it re-arms on a small premium
+	gated := b.gate.ShouldRebalance(premium, hasPositions)
+	return gated || event.Periodic
+}
+
+// snapshotHeight returns the height the market state of a rebalance is read
+// at, see pinHeight
+func (b *Bot) snapshotHeight(ctx context.Context, clients types.BlockchainClients, event events.Event) (int64, error) {
+	latest, err := endpoints.LatestHeight(ctx, clients.GRPCClient)
+	if err != nil {
+		return 0, fmt.Errorf("getting latest height: %w", err)
+	}
+
+	return pinHeight(event.Height, b.broadcaster.ConfirmedHeight(), latest)
+}
+
+// pinHeight returns the height of the triggering event, or the latest height
+// of the gRPC endpoint for events without one. It is raised to the height of
+// the signer's last confirmed transaction, so that an event from before the
+// transaction does not see positions it already changed. An endpoint that
+// has not caught up with either height yet is retried.
+func pinHeight(eventHeight, confirmedHeight, latest int64) (int64, error) {
+	floor := max(eventHeight, confirmedHeight)
+	if floor > latest {
+		return 0, retry.Retryable(fmt.Errorf("grpc endpoint at height %d has not reached height %d", latest, floor))
+	}
+
+	if eventHeight == 0 {
+		return latest, nil
+	}
+
+	return floor, nil
+}
+
+// Shutdown withdraws every position the bot holds in the power pool when
+// withdraw_on_shutdown is set, and does nothing otherwise. It must only be
+// called once event handling has stopped.
+func (b *Bot) Shutdown() error {
+	if !b.cfg.WithdrawOnShutdown {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.shutdownTimeout)
+	defer cancel()
+
+	clients, err := b.clients.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("getting clients: %w", err)
+	}
+
+	pool := types.Pool{ID: b.cfg.PowerPool.PoolId}
+
+	userPositions, err := queries.GetUserPositions(ctx, clients.CLClient, pool, b.address)
+	if err != nil {
+		return fmt.Errorf("finding user positions: %w", err)
+	}
+
+	if len(userPositions.Positions) == 0 {
+		b.l.Info("No positions to withdraw")
+		return nil
+	}
+
+	msgs := liquidity.RemovePreviousPositions(b.l, userPositions.Positions)
+
+	if b.cfg.DryRun {
+		b.l.Info("Dry run, positions not withdrawn", zap.Int("positions", len(msgs)))
+		return nil
+	}
+
+	res, err := b.broadcast(ctx, clients, msgs, time.Time{})
+	if err != nil {
+		return fmt.Errorf("withdrawing positions: %w", err)
+	}
+
+	b.l.Info("Withdrew all positions",
+		zap.Int("positions", len(msgs)),
+		zap.String("transaction hash", res.Hash.String()),
+	)
+
+	return nil
+}
+
+// simulate simulates the messages and logs a report of the transaction that
+// would have been broadcast
+func (b *Bot) simulate(ctx context.Context, clients types.BlockchainClients, pool types.ConcentratedPool, msgs []sdk.Msg) error {
+	simRes, err := b.broadcaster.Simulate(clients.CosmosClient, msgs...)
+	if err != nil {
+		b.l.Warn("Dry run simulation failed",
+			zap.Int("messages", len(msgs)),
+			zap.Any("report", liquidity.ReportMessages(pool, msgs, nil)),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	gasLimit, fees, err := b.broadcaster.GasAndFees(ctx, clients, simRes.GasInfo.GasUsed)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+
+	var responses []*codectypes.Any
+	if simRes.Result != nil {
+		responses = simRes.Result.MsgResponses
+	}
+
+	b.l.Info("Dry run, transaction not broadcast",
+		zap.Int("messages", len(msgs)),
+		zap.Uint64("gas_used", simRes.GasInfo.GasUsed),
+		zap.Uint64("gas_limit", gasLimit),
+		zap.String("fees", fees),
+		zap.Any("report", liquidity.ReportMessages(pool, msgs, responses)),
+	)
+
+	if simRes.GasInfo.GasUsed > gasLimit {
+		b.l.Warn("Simulated gas exceeds the gas limit",
+			zap.Uint64("gas_used", simRes.GasInfo.GasUsed),
+			zap.Uint64("gas_limit", gasLimit),
+		)
+	}
+
+	return nil
+}
+
+// broadcast submits the messages and waits until the transaction has been
+// included in a block or the confirmation timeout passes, see submit and
+// confirm
+func (b *Bot) broadcast(ctx context.Context, clients types.BlockchainClients, msgs []sdk.Msg, receivedAt time.Time) (*ctypes.ResultTx, error) {
+	resp, err := b.submit(ctx, clients, msgs, receivedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.confirm(ctx, clients, resp)
+}
+
+// submit broadcasts the messages without waiting for the transaction to be
+// included in a block. receivedAt is the time the event that triggered the
+// transaction was received, if any.
+func (b *Bot) submit(ctx context.Context, clients types.BlockchainClients, msgs []sdk.Msg, receivedAt time.Time) (*sdk.TxResponse, error) {
+	poolID := b.cfg.PowerPool.PoolId
+
+	resp, err := b.broadcaster.Broadcast(ctx, clients, msgs...)
+	if err != nil {
+		b.metrics.Transaction(poolID, metrics.TxFailure)
+		return nil, fmt.Errorf("broadcasting transaction: %w", err)
+	}
+
+	if !receivedAt.IsZero() {
+		b.metrics.Broadcast(poolID, receivedAt)
+	}
+
+	b.l.Info("Transaction submitted, waiting for confirmation",
+		zap.String("transaction hash", resp.TxHash),
+		zap.Int("messages", len(msgs)),
+	)
+
+	return resp, nil
+}
+
+// confirm waits until the submitted transaction has been included in a block
+// or the confirmation timeout passes. The positions changed by the
+// transaction are recorded from its events.
+func (b *Bot) confirm(ctx context.Context, clients types.BlockchainClients, resp *sdk.TxResponse) (*ctypes.ResultTx, error) {
+	poolID := b.cfg.PowerPool.PoolId
+
+	waitCtx, cancel := context.WithTimeout(ctx, b.confirmationTimeout)
+	defer cancel()
+
+	res, err := transactions.WaitForTx(waitCtx, clients.CosmosClient, resp.TxHash)
+	if res != nil {
+		// Later rebalances must read state that includes the transaction
+		b.broadcaster.Confirmed(res.Height)
+	}
+	if err != nil {
+		b.metrics.Transaction(poolID, metrics.TxFailure)
+		return nil, err
+	}
+
+	b.metrics.Transaction(poolID, metrics.TxSuccess)
+
+	b.l.Info("Transaction confirmed",
+		zap.String("transaction hash", resp.TxHash),
+		zap.Int64("height", res.Height),
+		zap.Int64("gas_wanted", res.TxResult.GasWanted),
+		zap.Int64("gas_used", res.TxResult.GasUsed),
+	)
+
+	positionEvents, err := liquidity.ParsePositionEvents(res.TxResult.Events)
+	if err != nil {
+		return res, fmt.Errorf("parsing events of tx %s: %w", resp.TxHash, err)
+	}
+
+	b.recordPositions(poolID, positionEvents)
+
+	return res, nil
+}
+
+// setPositions replaces the recorded positions with the queried positions
+func (b *Bot) setPositions(poolID uint64, positions []model.FullPositionBreakdown) {
+	b.positions = make(map[uint64]position, len(positions))
+	for _, p := range positions {
+		b.positions[p.Position.PositionId] = position{
+			liquidity: p.Position.Liquidity,
+			amount0:   p.Asset0.Amount,
+			amount1:   p.Asset1.Amount,
+		}
+	}
+
+	b.publishPositions(poolID)
+}
+
+// recordPositions applies the changes of position events to the recorded
+// positions
+func (b *Bot) recordPositions(poolID uint64, events []liquidity.PositionEvent) {
+	for _, e := range events {
+		p, ok := b.positions[e.PositionID]
+		if !ok {
+			p = position{liquidity: osmomath.ZeroDec(), amount0: sdkmath.ZeroInt(), amount1: sdkmath.ZeroInt()}
+		}
+
+		// Withdrawals are reported with negative amounts and liquidity, so
+		// every event adds its signed deltas
+		p.liquidity = p.liquidity.Add(e.Liquidity)
+		p.amount0, p.amount1 = p.amount0.Add(e.Amount0), p.amount1.Add(e.Amount1)
+
+		if p.liquidity.IsPositive() {
+			b.positions[e.PositionID] = p
+		} else {
+			delete(b.positions, e.PositionID)
+		}
+
+		b.l.Info("Position updated",
+			zap.String("event", e.Type),
+			zap.Uint64("position_id", e.PositionID),
+			zap.Int64("lower_tick", e.LowerTick),
+			zap.Int64("upper_tick", e.UpperTick),
+			zap.String("liquidity_delta", e.Liquidity.String()),
+			zap.String("liquidity", p.liquidity.String()),
+			zap.String("amount0", e.Amount0.String()),
+			zap.String("amount1", e.Amount1.String()),
+		)
+	}
+
+	b.publishPositions(poolID)
+}
+
+// publishPositions exports the recorded positions as metrics
+func (b *Bot) publishPositions(poolID uint64) {
+	positions := make([]metrics.Position, 0, len(b.positions))
+	for id, p := range b.positions {
+		positions = append(positions, metrics.Position{
+			ID:        id,
+			Liquidity: toFloat(p.liquidity),
+			Amount0:   toFloat(osmomath.NewDecFromInt(p.amount0)),
+			Amount1:   toFloat(osmomath.NewDecFromInt(p.amount1)),
+		})
+	}
+
+	b.metrics.SetPositions(poolID, positions)
+}
+
+func toFloat(d osmomath.Dec) float64 {
+	f, err := d.Float64()
+	if err != nil {
+		return 0
+	}
+	return f
+}
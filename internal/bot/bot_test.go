@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/osmosis-labs/osmosis/osmomath"
+	cltypes "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/types"
+	"go.uber.org/zap"
+	"gotest.tools/assert"
+
+	"github.com/margined-protocol/flood/internal/events"
+	"github.com/margined-protocol/flood/internal/liquidity"
+	"github.com/margined-protocol/flood/internal/metrics"
+	"github.com/margined-protocol/flood/internal/retry"
+)
+
+func TestPinHeight(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     int64
+		confirmed int64
+		latest    int64
+		want      int64
+	}{
+		{"event height", 100, 0, 105, 100},
+		{"no event height", 0, 0, 105, 105},
+		{"event below last confirmed transaction", 100, 103, 105, 103},
+		{"event above last confirmed transaction", 104, 103, 105, 104},
+		{"no event height after a transaction", 0, 103, 105, 105},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			height, err := pinHeight(tt.event, tt.confirmed, tt.latest)
+			assert.NilError(t, err)
+			assert.Equal(t, height, tt.want)
+		})
+	}
+}
+
+func TestPinHeightWaitsForEndpoint(t *testing.T) {
+	_, err := pinHeight(100, 0, 99)
+	assert.Assert(t, retry.IsRetryable(err))
+
+	// The endpoint has not caught up with the last confirmed transaction
+	_, err = pinHeight(100, 106, 105)
+	assert.Assert(t, retry.IsRetryable(err))
+	assert.ErrorContains(t, err, "has not reached height 106")
+}
+
+func TestRecordPositionsPartialWithdrawal(t *testing.T) {
+	b := &Bot{l: zap.NewNop(), positions: make(map[uint64]position), metrics: metrics.New()}
+
+	b.recordPositions(1, []liquidity.PositionEvent{{
+		Type:       cltypes.TypeEvtCreatePosition,
+		PositionID: 7,
+		Liquidity:  osmomath.NewDec(10),
+		Amount0:    sdkmath.NewInt(1000),
+		Amount1:    sdkmath.NewInt(500),
+	}})
+
+	// Withdrawals carry negative amounts and liquidity
+	b.recordPositions(1, []liquidity.PositionEvent{{
+		Type:       cltypes.TypeEvtWithdrawPosition,
+		PositionID: 7,
+		Liquidity:  osmomath.NewDec(-4),
+		Amount0:    sdkmath.NewInt(-400),
+		Amount1:    sdkmath.NewInt(-200),
+	}})
+
+	p, ok := b.positions[7]
+	assert.Assert(t, ok)
+	assert.Assert(t, p.liquidity.Equal(osmomath.NewDec(6)))
+	assert.Assert(t, p.amount0.Equal(sdkmath.NewInt(600)))
+	assert.Assert(t, p.amount1.Equal(sdkmath.NewInt(300)))
+}
+
+func TestPeriodicEventBypassesPremiumGate(t *testing.T) {
+	b := &Bot{gate: liquidity.NewPremiumGate(0.01, 0.0025)}
+	b.gate.Record(0.02)
+
+	swap := events.Event{Count: 1}
+	timer := events.Event{Count: 1, Periodic: true}
+
+	// The premium has not moved since the last rebalance
+	assert.Assert(t, !b.shouldRebalance(swap, 0.02, true))
+	assert.Assert(t, b.shouldRebalance(timer, 0.02, true))
+
+	// Within the band a quiet pool is still repriced by the timer
+	assert.Assert(t, !b.shouldRebalance(swap, 0.001, true))
+	assert.Assert(t, b.shouldRebalance(timer, 0.001, true))
+}
@@ -0,0 +1,195 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/events"
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+const defaultCoalesceWindow = 500 * time.Millisecond
+
+// Workers runs the bot of every pool on its own goroutine, so that a slow
+// pool does not hold up the others, and routes each event to the bots
+// subscribed to its query
+type Workers struct {
+	l              *zap.Logger
+	coalesceWindow time.Duration
+	bots           []*Bot
+	routes         map[string][]*Bot
+}
+
+// NewWorkers returns an empty set of workers that collapse the events of a
+// pool arriving within the coalesce window into one rebalance. A zero window
+// falls back to the default.
+func NewWorkers(l *zap.Logger, coalesceWindow time.Duration) *Workers {
+	if coalesceWindow <= 0 {
+		coalesceWindow = defaultCoalesceWindow
+	}
+
+	return &Workers{
+		l:              l,
+		coalesceWindow: coalesceWindow,
+		routes:         make(map[string][]*Bot),
+	}
+}
+
+// Add adds the bot of a pool, handling the events of the queries
+func (w *Workers) Add(b *Bot, queries ...string) {
+	w.bots = append(w.bots, b)
+	for _, query := range queries {
+		w.routes[query] = append(w.routes[query], b)
+	}
+}
+
+// Bots returns the bots of all pools
+func (w *Workers) Bots() []*Bot {
+	return w.bots
+}
+
+// Reload queues the reloaded config of every pool, given in the order the
+// bots were added. The configs of all pools are checked first, so either
+// every bot gets its reload or none does.
+func (w *Workers) Reload(cfgs []*types.Config) error {
+	if len(cfgs) != len(w.bots) {
+		return fmt.Errorf("got configs for %d pools, want %d", len(cfgs), len(w.bots))
+	}
+
+	reloads := make([]reload, len(cfgs))
+	for i, cfg := range cfgs {
+		r, err := newReload(cfg)
+		if err != nil {
+			return fmt.Errorf("pool %d: %w", cfg.PowerPool.PoolId, err)
+		}
+		reloads[i] = r
+	}
+
+	for i, b := range w.bots {
+		b.queueReload(reloads[i])
+	}
+
+	return nil
+}
+
+// Run dispatches events to the bots until the channel is closed or the
+// context is done. The events of a pool are collapsed over the coalesce
+// window, and while its bot is busy further events are collapsed into the
+// one pending event, since a rebalance always reads fresh state. Catch-up
+// and periodic events go to every bot and share the coalescing, so they
+// never overlap with a rebalance for a swap. If a bot stops with an error
+// the other bots are stopped and the error is returned.
+func (w *Workers) Run(ctx context.Context, eventCh <-chan events.Event) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	coalescers := make(map[*Bot]*events.Coalescer, len(w.bots))
+	for _, b := range w.bots {
+		inbox := make(chan events.Event, 1)
+
+		coalescer := events.NewCoalescer(w.coalesceWindow, func(event events.Event) {
+			deliver(inbox, event)
+		})
+		defer coalescer.Stop()
+		coalescers[b] = coalescer
+
+		wg.Add(1)
+		go func(b *Bot) {
+			defer wg.Done()
+
+			if err := b.Run(ctx, inbox); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("pool %d: %w", b.PoolID(), err))
+				mu.Unlock()
+				cancel()
+			}
+		}(b)
+	}
+
+	w.dispatch(ctx, eventCh, coalescers)
+
+	cancel()
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// dispatch routes events to the coalescers of the bots until the channel is
+// closed or the context is done
+func (w *Workers) dispatch(ctx context.Context, eventCh <-chan events.Event, coalescers map[*Bot]*events.Coalescer) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-eventCh:
+			if !ok {
+				return
+			}
+
+			bots := w.routes[event.Result.Query]
+			if events.Synthetic(event.Result.Query) {
+				bots = w.bots
+			}
+
+			if len(bots) == 0 {
+				w.l.Debug("No pool for event, dropping it", zap.String("query", event.Result.Query))
+			}
+
+			for _, b := range bots {
+				coalescers[b].Add(event)
+			}
+		}
+	}
+}
+
+// deliver puts an event in the inbox of a bot without blocking, collapsing
+// it with the event already waiting there if the bot is busy
+func deliver(inbox chan events.Event, event events.Event) {
+	for {
+		select {
+		case inbox <- event:
+			return
+		default:
+		}
+
+		select {
+		case pending := <-inbox:
+			event = events.Merge(pending, event)
+		default:
+		}
+	}
+}
+
+// LastRebalance returns the time of the latest successful rebalance of any
+// pool, or the zero time
+func (w *Workers) LastRebalance() time.Time {
+	var last time.Time
+	for _, b := range w.bots {
+		if t := b.LastRebalance(); t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// Shutdown shuts down the bot of every pool
+func (w *Workers) Shutdown() error {
+	var errs []error
+	for _, b := range w.bots {
+		if err := b.Shutdown(); err != nil {
+			errs = append(errs, fmt.Errorf("pool %d: %w", b.PoolID(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/margined-protocol/flood/internal/events"
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+func TestDeliverCollapsesIntoPendingEvent(t *testing.T) {
+	inbox := make(chan events.Event, 1)
+	first := events.Event{ReceivedAt: time.Now(), Height: 10, Count: 3}
+
+	deliver(inbox, first)
+	deliver(inbox, events.Event{ReceivedAt: time.Now(), Height: 11, Count: 2})
+
+	assert.Equal(t, len(inbox), 1)
+
+	pending := <-inbox
+	assert.Equal(t, pending.Height, int64(11))
+	assert.Equal(t, pending.Count, 5)
+	assert.Equal(t, pending.ReceivedAt, first.ReceivedAt)
+}
+
+func TestReloadIsAllOrNothing(t *testing.T) {
+	w := NewWorkers(nil, 0)
+	first := &Bot{reloads: make(chan reload, 1)}
+	second := &Bot{reloads: make(chan reload, 1)}
+	w.Add(first)
+	w.Add(second)
+
+	valid := &types.Config{PowerPool: types.PowerPool{PoolId: 1}}
+	invalid := &types.Config{PowerPool: types.PowerPool{PoolId: 2}, Strategy: "hodl"}
+
+	err := w.Reload([]*types.Config{valid, invalid})
+	assert.ErrorContains(t, err, "pool 2")
+	assert.Equal(t, len(first.reloads), 0)
+	assert.Equal(t, len(second.reloads), 0)
+
+	assert.NilError(t, w.Reload([]*types.Config{valid, valid}))
+	assert.Equal(t, len(first.reloads), 1)
+	assert.Equal(t, len(second.reloads), 1)
+}
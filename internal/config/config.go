@@ -1,15 +1,300 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/BurntSushi/toml"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/ignite/cli/ignite/pkg/cosmosclient"
+	"github.com/osmosis-labs/osmosis/osmomath"
 
+	"github.com/margined-protocol/flood/internal/endpoints"
+	"github.com/margined-protocol/flood/internal/events"
+	"github.com/margined-protocol/flood/internal/liquidity"
+	"github.com/margined-protocol/flood/internal/transactions"
 	"github.com/margined-protocol/flood/internal/types"
 )
 
-func LoadConfig(configPath string) (*types.Config, error) {
+const endpointCheckTimeout = 10 * time.Second
+
+// LoadConfig builds the config from, in increasing order of precedence, the
+// config file, the FLOOD_* environment variables and the flag overrides, and
+// validates it. The file is skipped if the path is empty. Keys in the file
+// that do not map to a config field are rejected.
+func LoadConfig(configPath string, overrides *Overrides) (*types.Config, error) {
+	return load(configPath, os.Environ(), overrides)
+}
+
+func load(configPath string, environ []string, overrides *Overrides) (*types.Config, error) {
 	var config types.Config
-	if _, err := toml.DecodeFile(configPath, &config); err != nil {
-		return nil, err
+
+	if configPath != "" {
+		md, err := toml.DecodeFile(configPath, &config)
+		if err != nil {
+			return nil, err
+		}
+
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return nil, fmt.Errorf("%s: unknown keys: %s", configPath, strings.Join(keys, ", "))
+		}
+	}
+
+	if err := applyEnv(&config, environ); err != nil {
+		return nil, fmt.Errorf("invalid environment:\n%w", err)
+	}
+
+	if err := applyFlags(&config, overrides); err != nil {
+		return nil, fmt.Errorf("invalid flags:\n%w", err)
+	}
+
+	if err := Validate(&config); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
 	}
+
 	return &config, nil
 }
+
+// Validate checks the values of the config, returning every problem found
+// with the key it was found in
+func Validate(cfg *types.Config) error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if cfg.AddressPrefix == "" {
+		invalid("address_prefix", "must be set")
+	}
+
+	if len(RPCAddresses(cfg)) == 0 {
+		invalid("rpc_server_address", "at least one RPC endpoint must be set")
+	}
+	if len(GRPCAddresses(cfg)) == 0 {
+		invalid("grpc_server_address", "at least one gRPC endpoint must be set")
+	}
+
+	if cfg.SignerAccount == "" {
+		invalid("signer_account", "must be set")
+	}
+
+	if cfg.Gas != "" && cfg.Gas != cosmosclient.GasAuto {
+		if _, err := strconv.ParseUint(cfg.Gas, 10, 64); err != nil {
+			invalid("gas", "must be %q or a whole number, got %q", cosmosclient.GasAuto, cfg.Gas)
+		}
+	}
+
+	if cfg.Fees != "" {
+		if _, err := sdk.ParseCoinsNormalized(cfg.Fees); err != nil {
+			invalid("fees", "must be coins such as \"10000uosmo\", got %q", cfg.Fees)
+		}
+	}
+
+	switch cfg.FeeMode {
+	case "", transactions.FeeModeFixed:
+	case transactions.FeeModeDynamic:
+		if _, err := sdk.ParseCoinNormalized(cfg.MaxFee); err != nil {
+			invalid("max_fee", "must be a coin such as \"100000uosmo\" when fee_mode is %q, got %q", transactions.FeeModeDynamic, cfg.MaxFee)
+		}
+	default:
+		invalid("fee_mode", "must be %q or %q, got %q", transactions.FeeModeFixed, transactions.FeeModeDynamic, cfg.FeeMode)
+	}
+
+	if cfg.RebalanceInterval < 0 {
+		invalid("rebalance_interval", "must not be negative, got %s", cfg.RebalanceInterval)
+	}
+	if cfg.RebalanceBlocks < 0 {
+		invalid("rebalance_interval_blocks", "must not be negative, got %d", cfg.RebalanceBlocks)
+	}
+
+	if cfg.MinSignerBalance != "" {
+		if _, err := sdk.ParseCoinNormalized(cfg.MinSignerBalance); err != nil {
+			invalid("min_signer_balance", "must be a coin such as \"1000000uosmo\", got %q", cfg.MinSignerBalance)
+		}
+	}
+
+	if len(cfg.Pools) > 0 {
+		if cfg.PowerPool != (types.PowerPool{}) {
+			invalid("power_pool", "cannot be combined with [[pools]], move it into a [[pools]] entry")
+		}
+		if cfg.Position != (types.Position{}) {
+			invalid("position", "cannot be combined with [[pools]], move it into the position of each [[pools]] entry")
+		}
+	}
+
+	seen := make(map[uint64]bool)
+	for i, pool := range Pools(cfg) {
+		keys := poolKeys{pool: "power_pool.", position: "position."}
+		if len(cfg.Pools) > 0 {
+			prefix := fmt.Sprintf("pools.%d.", i)
+			keys = poolKeys{pool: prefix, top: prefix, position: prefix + "position."}
+		}
+
+		if pool.PoolId != 0 && seen[pool.PoolId] {
+			invalid(keys.pool+"pool_id", "pool %d is configured more than once", pool.PoolId)
+		}
+		seen[pool.PoolId] = true
+
+		validatePool(cfg, pool, keys, invalid)
+	}
+
+	if _, err := transactions.RenderMemo(cfg.Memo, transactions.MemoData{}); err != nil {
+		invalid("memo", "%v", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// poolKeys are the key prefixes a pool is configured under, used to report
+// problems against the right key
+type poolKeys struct {
+	pool     string
+	top      string
+	position string
+}
+
+// validatePool checks the values of a pool
+func validatePool(cfg *types.Config, pool types.PoolConfig, keys poolKeys, invalid func(key, format string, args ...any)) {
+	if pool.PoolId == 0 {
+		invalid(keys.pool+"pool_id", "must be set")
+	}
+	if pool.BaseAsset == "" {
+		invalid(keys.pool+"base_asset", "must be set")
+	}
+	if pool.QuoteAsset == "" {
+		invalid(keys.pool+"quote_asset", "must be set")
+	}
+
+	hrp, _, err := bech32.DecodeAndConvert(pool.ContractAddress)
+	switch {
+	case err != nil:
+		invalid(keys.pool+"contract_address", "must be a bech32 address, got %q: %v", pool.ContractAddress, err)
+	case cfg.AddressPrefix != "" && hrp != cfg.AddressPrefix:
+		invalid(keys.pool+"contract_address", "must have the %q prefix, got %q", cfg.AddressPrefix, hrp)
+	}
+
+	position := pool.Position
+
+	if err := validateSpread(position.Spread); err != nil {
+		invalid(keys.position+"spread", "%v", err)
+	}
+	if position.LpSpread != "" {
+		if err := validateSpread(position.LpSpread); err != nil {
+			invalid(keys.position+"lp_spread", "%v", err)
+		}
+	}
+
+	// The default amounts are only deployed when the wallet balance is not
+	if !position.UseWalletBalance {
+		if position.DefaultToken0Amount <= 0 {
+			invalid(keys.position+"default_token_0_amount", "must be positive unless use_wallet_balance is set, got %d", position.DefaultToken0Amount)
+		}
+		if position.DefaultToken1Amount <= 0 {
+			invalid(keys.position+"default_token_1_amount", "must be positive unless use_wallet_balance is set, got %d", position.DefaultToken1Amount)
+		}
+	}
+	if position.Ranges < 0 {
+		invalid(keys.position+"ranges", "must not be negative, got %d", position.Ranges)
+	}
+	if position.PremiumThreshold < 0 {
+		invalid(keys.position+"premium_threshold", "must not be negative, got %v", position.PremiumThreshold)
+	}
+	if position.PremiumHysteresis < 0 {
+		invalid(keys.position+"premium_hysteresis", "must not be negative, got %v", position.PremiumHysteresis)
+	}
+
+	if _, err := liquidity.ParseSlippageTolerance(position.SlippageTolerance); err != nil {
+		invalid(keys.position+"slippage_tolerance", "%v", err)
+	}
+
+	if _, err := liquidity.NewStrategy(pool.Strategy, ForPool(cfg, pool)); err != nil {
+		invalid(keys.top+"strategy", "%v", err)
+	}
+
+	if _, err := events.SwapQueries(pool.EventQueries, pool.PoolId); err != nil {
+		invalid(keys.top+"event_queries", "%v", err)
+	}
+	for _, id := range pool.TriggerPools {
+		if id == 0 || id == pool.PoolId {
+			invalid(keys.top+"trigger_pools", "must be the ids of other pools, got %d", id)
+		}
+	}
+}
+
+// validateSpread checks that a spread is a decimal in (0, 1)
+func validateSpread(spread string) error {
+	if spread == "" {
+		return errors.New("must be set")
+	}
+
+	d, err := osmomath.NewDecFromStr(spread)
+	if err != nil {
+		return fmt.Errorf("must be a decimal, got %q", spread)
+	}
+
+	if !d.IsPositive() || d.GTE(osmomath.OneDec()) {
+		return fmt.Errorf("must be in (0, 1), got %s", spread)
+	}
+
+	return nil
+}
+
+// CheckEndpoints checks that every configured RPC and gRPC endpoint serves
+// its latest block, returning an error for each one that does not
+func CheckEndpoints(ctx context.Context, cfg *types.Config) error {
+	var errs []error
+
+	check := func(kind string, addresses []string, checker endpoints.Checker) {
+		for _, address := range addresses {
+			checkCtx, cancel := context.WithTimeout(ctx, endpointCheckTimeout)
+			_, err := checker(checkCtx, address)
+			cancel()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s endpoint %s is unreachable: %w", kind, address, err))
+			}
+		}
+	}
+
+	check("rpc", RPCAddresses(cfg), endpoints.RPCChecker)
+	check("grpc", GRPCAddresses(cfg), endpoints.GRPCChecker)
+
+	return errors.Join(errs...)
+}
+
+// RPCAddresses returns the configured RPC endpoints in order of preference
+func RPCAddresses(cfg *types.Config) []string {
+	return mergeAddresses(cfg.RPCServerAddress, cfg.RPCServerAddresses)
+}
+
+// GRPCAddresses returns the configured gRPC endpoints in order of preference
+func GRPCAddresses(cfg *types.Config) []string {
+	return mergeAddresses(cfg.GRPCServerAddress, cfg.GRPCServerAddresses)
+}
+
+// mergeAddresses puts the single address ahead of the list, dropping blanks
+// and duplicates
+func mergeAddresses(address string, addresses []string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+
+	for _, a := range append([]string{address}, addresses...) {
+		if a == "" || seen[a] {
+			continue
+		}
+		seen[a] = true
+		merged = append(merged, a)
+	}
+
+	return merged
+}
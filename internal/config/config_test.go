@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+func validConfig() types.Config {
+	return types.Config{
+		AddressPrefix:     "osmo",
+		Fees:              "10000uosmo",
+		Gas:               "250000",
+		GRPCServerAddress: "localhost:9090",
+		RPCServerAddress:  "http://localhost:26657",
+		SignerAccount:     "bot-1",
+		PowerPool: types.PowerPool{
+			PoolId:          1,
+			BaseAsset:       "uosmo",
+			QuoteAsset:      "uion",
+			ContractAddress: "osmo1zttzenjrnfr8tgrsfyu8kw0eshd8mas7yky43jjtactkhvmtkg2qz769y2",
+		},
+		Position: types.Position{
+			DefaultToken0Amount: 1000000,
+			DefaultToken1Amount: 1000000,
+			Spread:              "0.05",
+		},
+	}
+}
+
+func TestLoadConfigExamples(t *testing.T) {
+	for _, path := range []string{"../../configs/config.example.toml", "../../configs/config.dev.toml"} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			_, err := load(path, nil, nil)
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	example, err := os.ReadFile("../../configs/config.example.toml")
+	assert.NilError(t, err)
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	contents := strings.Replace(string(example), "[position]\n", "[position]\nsprad = \"0.05\"\n", 1)
+	assert.NilError(t, os.WriteFile(path, []byte(contents), 0o600))
+
+	_, err = load(path, nil, nil)
+	assert.ErrorContains(t, err, "unknown keys: position.sprad")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *types.Config)
+		want   string
+	}{
+		{"valid", func(cfg *types.Config) {}, ""},
+		{"missing spread", func(cfg *types.Config) { cfg.Position.Spread = "" }, "position.spread: must be set"},
+		{"spread not a decimal", func(cfg *types.Config) { cfg.Position.Spread = "5%" }, "position.spread: must be a decimal"},
+		{"spread of one", func(cfg *types.Config) { cfg.Position.Spread = "1" }, "position.spread: must be in (0, 1)"},
+		{"zero spread", func(cfg *types.Config) { cfg.Position.Spread = "0" }, "position.spread: must be in (0, 1)"},
+		{"zero amount", func(cfg *types.Config) { cfg.Position.DefaultToken0Amount = 0 }, "position.default_token_0_amount: must be positive"},
+		{"negative amount", func(cfg *types.Config) { cfg.Position.DefaultToken1Amount = -1 }, "position.default_token_1_amount: must be positive"},
+		{"no amounts with wallet balance", func(cfg *types.Config) {
+			cfg.Position.DefaultToken0Amount = 0
+			cfg.Position.DefaultToken1Amount = 0
+			cfg.Position.UseWalletBalance = true
+		}, ""},
+		{"contract address not bech32", func(cfg *types.Config) { cfg.PowerPool.ContractAddress = "osmo1abc" }, "power_pool.contract_address: must be a bech32 address"},
+		{"contract address wrong prefix", func(cfg *types.Config) {
+			cfg.PowerPool.ContractAddress = "cosmos1zttzenjrnfr8tgrsfyu8kw0eshd8mas7yky43jjtactkhvmtkg2qhrzanv"
+		}, "power_pool.contract_address: must have the \"osmo\" prefix, got \"cosmos\""},
+		{"no endpoints", func(cfg *types.Config) { cfg.RPCServerAddress = "" }, "rpc_server_address: at least one RPC endpoint must be set"},
+		{"unknown fee mode", func(cfg *types.Config) { cfg.FeeMode = "auto" }, "fee_mode: must be \"fixed\" or \"dynamic\""},
+		{"dynamic fees without max fee", func(cfg *types.Config) { cfg.FeeMode = "dynamic" }, "max_fee: must be a coin"},
+		{"unknown strategy", func(cfg *types.Config) { cfg.Strategy = "hodl" }, "strategy: unknown strategy \"hodl\""},
+		{"invalid event query", func(cfg *types.Config) {
+			cfg.EventQueries = []string{"token_swapped.pool_id == '{{.PoolID}}'"}
+		}, "event_queries: invalid query"},
+		{"zero trigger pool", func(cfg *types.Config) { cfg.TriggerPools = []uint64{0} }, "trigger_pools: must be the ids of other pools, got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+
+			err := Validate(&cfg)
+			if tt.want == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Position.Spread = ""
+	cfg.Position.DefaultToken0Amount = 0
+
+	err := Validate(&cfg)
+	assert.ErrorContains(t, err, "position.spread")
+	assert.ErrorContains(t, err, "position.default_token_0_amount")
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+// EnvPrefix is the prefix of the environment variables that override config
+// keys. FLOOD_CONFIG sets the path of the config file.
+const EnvPrefix = "FLOOD_"
+
+// ConfigPathEnv sets the path of the config file when -c is not given
+const ConfigPathEnv = EnvPrefix + "CONFIG"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Overrides holds the config keys set on the command line, in the order they
+// were given
+type Overrides struct {
+	values []override
+}
+
+type override struct {
+	key   string
+	value string
+}
+
+// flagValue records a flag as an override of its config key
+type flagValue struct {
+	overrides *Overrides
+	key       string
+	isBool    bool
+}
+
+func (f *flagValue) String() string { return "" }
+
+func (f *flagValue) Set(value string) error {
+	f.overrides.values = append(f.overrides.values, override{key: f.key, value: value})
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+// RegisterFlags registers a flag for every config key on the flag set. The
+// flag of a key is the key with underscores replaced by dashes, so
+// power_pool.pool_id is set with -power-pool.pool-id. Keys of [[pools]]
+// entries get no flag, as the entries are only known once the file is read,
+// and are overridden from the environment only.
+func RegisterFlags(fs *flag.FlagSet) *Overrides {
+	overrides := &Overrides{}
+
+	for _, field := range fields(reflect.ValueOf(&types.Config{}).Elem(), "") {
+		fs.Var(&flagValue{
+			overrides: overrides,
+			key:       field.key,
+			isBool:    field.value.Kind() == reflect.Bool,
+		}, FlagName(field.key), fmt.Sprintf("overrides %s in the config file and %s", field.key, EnvName(field.key)))
+	}
+
+	return overrides
+}
+
+// FlagName returns the name of the flag that overrides a config key
+func FlagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// EnvName returns the name of the environment variable that overrides a
+// config key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyEnv sets the config keys from the FLOOD_* variables of environ.
+// Unknown FLOOD_* variables are rejected so that typos are not ignored.
+func applyEnv(cfg *types.Config, environ []string) error {
+	byName := make(map[string]field)
+	for _, field := range fields(reflect.ValueOf(cfg).Elem(), "") {
+		byName[EnvName(field.key)] = field
+	}
+
+	var errs []error
+	var unknown []string
+
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, EnvPrefix) || name == ConfigPathEnv {
+			continue
+		}
+
+		field, ok := byName[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		if err := setValue(field.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		errs = append(errs, fmt.Errorf("unknown environment variables: %s", strings.Join(unknown, ", ")))
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyFlags sets the config keys given on the command line
+func applyFlags(cfg *types.Config, overrides *Overrides) error {
+	if overrides == nil {
+		return nil
+	}
+
+	byKey := make(map[string]field)
+	for _, field := range fields(reflect.ValueOf(cfg).Elem(), "") {
+		byKey[field.key] = field
+	}
+
+	var errs []error
+	for _, o := range overrides.values {
+		if err := setValue(byKey[o.key].value, o.value); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", FlagName(o.key), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// field is a settable config value and its dotted TOML key
+type field struct {
+	key   string
+	value reflect.Value
+}
+
+// fields lists the config values of a struct that can be overridden,
+// descending into tables and the existing entries of arrays of tables
+func fields(v reflect.Value, prefix string) []field {
+	var out []field
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + name
+		value := v.Field(i)
+
+		switch {
+		case value.Kind() == reflect.Struct:
+			out = append(out, fields(value, key+".")...)
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct:
+			// Entries of arrays of tables are keyed by their index
+			for j := 0; j < value.Len(); j++ {
+				out = append(out, fields(value.Index(j), fmt.Sprintf("%s.%d.", key, j))...)
+			}
+		default:
+			out = append(out, field{key: key, value: value})
+		}
+	}
+
+	return out
+}
+
+// setValue parses raw into the value. Lists are comma separated.
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(list.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+const devConfig = "../../configs/config.dev.toml"
+
+func TestOverridePrecedence(t *testing.T) {
+	fs := flag.NewFlagSet("flood", flag.ContinueOnError)
+	overrides := RegisterFlags(fs)
+	assert.NilError(t, fs.Parse([]string{"-power-pool.pool-id", "7", "-dry-run"}))
+
+	cfg, err := load(devConfig, []string{
+		"FLOOD_POWER_POOL_POOL_ID=5",
+		"FLOOD_POSITION_SPREAD=0.1",
+		"FLOOD_RPC_SERVER_ADDRESSES=https://a.example.com:443, https://b.example.com:443",
+		"FLOOD_RETRY_BACKOFF=2s",
+		"FLOOD_TRIGGER_POOLS=1,1223",
+		"FLOOD_CONFIG=ignored.toml",
+		"HOME=/root",
+	}, overrides)
+	assert.NilError(t, err)
+
+	// Flags win over the environment, which wins over the file
+	assert.Equal(t, cfg.PowerPool.PoolId, uint64(7))
+	assert.Equal(t, cfg.Position.Spread, "0.1")
+	assert.Equal(t, cfg.DryRun, true)
+	assert.Equal(t, cfg.RetryBackoff, 2*time.Second)
+	assert.DeepEqual(t, cfg.RPCServerAddresses, []string{"https://a.example.com:443", "https://b.example.com:443"})
+	assert.DeepEqual(t, cfg.TriggerPools, []uint64{1, 1223})
+
+	// Keys that are not overridden keep the value from the file
+	assert.Equal(t, cfg.PowerPool.BaseAsset, "uosmo")
+	assert.Equal(t, cfg.GasAdjustment, float64(3))
+}
+
+func TestOverridesWithoutFile(t *testing.T) {
+	cfg, err := load("", []string{
+		"FLOOD_ADDRESS_PREFIX=osmo",
+		"FLOOD_RPC_SERVER_ADDRESS=http://localhost:26657",
+		"FLOOD_GRPC_SERVER_ADDRESS=localhost:9090",
+		"FLOOD_SIGNER_ACCOUNT=bot-1",
+		"FLOOD_POWER_POOL_POOL_ID=1",
+		"FLOOD_POWER_POOL_BASE_ASSET=uosmo",
+		"FLOOD_POWER_POOL_QUOTE_ASSET=uion",
+		"FLOOD_POWER_POOL_CONTRACT_ADDRESS=osmo1zttzenjrnfr8tgrsfyu8kw0eshd8mas7yky43jjtactkhvmtkg2qz769y2",
+		"FLOOD_POSITION_DEFAULT_TOKEN_0_AMOUNT=1000000",
+		"FLOOD_POSITION_DEFAULT_TOKEN_1_AMOUNT=1000000",
+		"FLOOD_POSITION_SPREAD=0.05",
+	}, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.PowerPool.PoolId, uint64(1))
+}
+
+func TestOverrideErrors(t *testing.T) {
+	_, err := load(devConfig, []string{"FLOOD_POSITION_SPRAD=0.1"}, nil)
+	assert.ErrorContains(t, err, "unknown environment variables: FLOOD_POSITION_SPRAD")
+
+	_, err = load(devConfig, []string{"FLOOD_RETRY_ATTEMPTS=three"}, nil)
+	assert.ErrorContains(t, err, "FLOOD_RETRY_ATTEMPTS")
+
+	_, err = load(devConfig, []string{"FLOOD_TRIGGER_POOLS=1,two"}, nil)
+	assert.ErrorContains(t, err, "FLOOD_TRIGGER_POOLS")
+
+	fs := flag.NewFlagSet("flood", flag.ContinueOnError)
+	overrides := RegisterFlags(fs)
+	assert.NilError(t, fs.Parse([]string{"-shutdown-timeout", "soon"}))
+
+	_, err = load(devConfig, nil, overrides)
+	assert.ErrorContains(t, err, "-shutdown-timeout")
+}
+
+func TestEveryKeyHasAFlag(t *testing.T) {
+	fs := flag.NewFlagSet("flood", flag.ContinueOnError)
+	RegisterFlags(fs)
+
+	for _, name := range []string{"address-prefix", "key.root-dir", "power-pool.contract-address", "position.slippage-tolerance", "http-address"} {
+		assert.Assert(t, fs.Lookup(name) != nil, name)
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import "github.com/margined-protocol/flood/internal/types"
+
+// Pools returns the configured pools. A config without [[pools]] manages the
+// single pool of [power_pool] with the top level strategy and [position].
+// Pools without a strategy, event queries or trigger pools use the top level
+// ones.
+func Pools(cfg *types.Config) []types.PoolConfig {
+	if len(cfg.Pools) == 0 {
+		return []types.PoolConfig{{
+			PoolId:          cfg.PowerPool.PoolId,
+			BaseAsset:       cfg.PowerPool.BaseAsset,
+			QuoteAsset:      cfg.PowerPool.QuoteAsset,
+			ContractAddress: cfg.PowerPool.ContractAddress,
+			Strategy:        cfg.Strategy,
+			EventQueries:    cfg.EventQueries,
+			TriggerPools:    cfg.TriggerPools,
+			Position:        cfg.Position,
+		}}
+	}
+
+	pools := make([]types.PoolConfig, len(cfg.Pools))
+	for i, pool := range cfg.Pools {
+		if pool.Strategy == "" {
+			pool.Strategy = cfg.Strategy
+		}
+		if len(pool.EventQueries) == 0 {
+			pool.EventQueries = cfg.EventQueries
+		}
+		if len(pool.TriggerPools) == 0 {
+			pool.TriggerPools = cfg.TriggerPools
+		}
+		pools[i] = pool
+	}
+
+	return pools
+}
+
+// ForPool returns a copy of the config for a single pool, with the power
+// pool, strategy, event queries, trigger pools and position replaced by those
+// of the pool
+func ForPool(cfg *types.Config, pool types.PoolConfig) *types.Config {
+	poolCfg := *cfg
+	poolCfg.PowerPool = types.PowerPool{
+		PoolId:          pool.PoolId,
+		BaseAsset:       pool.BaseAsset,
+		QuoteAsset:      pool.QuoteAsset,
+		ContractAddress: pool.ContractAddress,
+	}
+	poolCfg.Strategy = pool.Strategy
+	poolCfg.EventQueries = pool.EventQueries
+	poolCfg.TriggerPools = pool.TriggerPools
+	poolCfg.Position = pool.Position
+	poolCfg.Pools = nil
+
+	return &poolCfg
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+func poolsConfig() types.Config {
+	cfg := validConfig()
+
+	pool := types.PoolConfig{
+		PoolId:          cfg.PowerPool.PoolId,
+		BaseAsset:       cfg.PowerPool.BaseAsset,
+		QuoteAsset:      cfg.PowerPool.QuoteAsset,
+		ContractAddress: cfg.PowerPool.ContractAddress,
+		Position:        cfg.Position,
+	}
+	other := pool
+	other.PoolId = 2
+	other.Strategy = "market_make"
+	other.Position.Spread = "0.1"
+
+	cfg.PowerPool = types.PowerPool{}
+	cfg.Position = types.Position{}
+	cfg.Pools = []types.PoolConfig{pool, other}
+
+	return cfg
+}
+
+func TestPoolsFromPowerPool(t *testing.T) {
+	cfg := validConfig()
+	cfg.Strategy = "market_make"
+
+	pools := Pools(&cfg)
+	assert.Equal(t, len(pools), 1)
+	assert.Equal(t, pools[0].PoolId, cfg.PowerPool.PoolId)
+	assert.Equal(t, pools[0].ContractAddress, cfg.PowerPool.ContractAddress)
+	assert.Equal(t, pools[0].Strategy, "market_make")
+	assert.Equal(t, pools[0].Position, cfg.Position)
+}
+
+func TestPoolsInheritStrategy(t *testing.T) {
+	cfg := poolsConfig()
+	cfg.Strategy = "default"
+
+	pools := Pools(&cfg)
+	assert.Equal(t, len(pools), 2)
+	assert.Equal(t, pools[0].Strategy, "default")
+	assert.Equal(t, pools[1].Strategy, "market_make")
+}
+
+func TestPoolsInheritEventQueries(t *testing.T) {
+	cfg := poolsConfig()
+	cfg.EventQueries = []string{"token_swapped.pool_id = '{{.PoolID}}'"}
+	cfg.TriggerPools = []uint64{1}
+	cfg.Pools[1].TriggerPools = []uint64{3}
+
+	pools := Pools(&cfg)
+	assert.DeepEqual(t, pools[0].EventQueries, cfg.EventQueries)
+	assert.DeepEqual(t, pools[1].EventQueries, cfg.EventQueries)
+	assert.DeepEqual(t, pools[0].TriggerPools, []uint64{1})
+	assert.DeepEqual(t, pools[1].TriggerPools, []uint64{3})
+}
+
+func TestForPool(t *testing.T) {
+	cfg := poolsConfig()
+
+	poolCfg := ForPool(&cfg, cfg.Pools[1])
+	assert.Equal(t, poolCfg.PowerPool.PoolId, uint64(2))
+	assert.Equal(t, poolCfg.Position.Spread, "0.1")
+	assert.Equal(t, poolCfg.Strategy, "market_make")
+	assert.Equal(t, len(poolCfg.Pools), 0)
+	assert.Equal(t, poolCfg.RPCServerAddress, cfg.RPCServerAddress)
+
+	// The original config is unchanged
+	assert.Equal(t, len(cfg.Pools), 2)
+}
+
+func TestValidatePools(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *types.Config)
+		want   string
+	}{
+		{"valid", func(cfg *types.Config) {}, ""},
+		{"invalid spread", func(cfg *types.Config) { cfg.Pools[1].Position.Spread = "2" }, "pools.1.position.spread: must be in (0, 1)"},
+		{"missing contract", func(cfg *types.Config) { cfg.Pools[0].ContractAddress = "" }, "pools.0.contract_address: must be a bech32 address"},
+		{"duplicate pool", func(cfg *types.Config) { cfg.Pools[1].PoolId = cfg.Pools[0].PoolId }, "pools.1.pool_id: pool 1 is configured more than once"},
+		{"unknown strategy", func(cfg *types.Config) { cfg.Pools[0].Strategy = "hodl" }, "pools.0.strategy: unknown strategy"},
+		{"invalid event query", func(cfg *types.Config) { cfg.Pools[1].EventQueries = []string{"pool_id = {{.PoolID}"} }, "pools.1.event_queries: parsing query template"},
+		{"triggered by itself", func(cfg *types.Config) { cfg.Pools[0].TriggerPools = []uint64{1} }, "pools.0.trigger_pools: must be the ids of other pools, got 1"},
+		{"combined with power pool", func(cfg *types.Config) { cfg.PowerPool.PoolId = 3 }, "power_pool: cannot be combined with [[pools]]"},
+		{"combined with position", func(cfg *types.Config) { cfg.Position.Spread = "0.05" }, "position: cannot be combined with [[pools]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := poolsConfig()
+			tt.modify(&cfg)
+
+			err := Validate(&cfg)
+			if tt.want == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestPoolOverridesFromEnv(t *testing.T) {
+	cfg := poolsConfig()
+
+	assert.NilError(t, applyEnv(&cfg, []string{"FLOOD_POOLS_1_POSITION_SPREAD=0.2"}))
+	assert.Equal(t, cfg.Pools[1].Position.Spread, "0.2")
+}
+
+func TestLoadConfigPools(t *testing.T) {
+	contents := `
+address_prefix = "osmo"
+fees = "10000uosmo"
+grpc_server_address = "localhost:9090"
+rpc_server_address = "http://localhost:26657"
+signer_account = "bot-1"
+
+[[pools]]
+pool_id = 1
+base_asset = "uosmo"
+quote_asset = "uion"
+contract_address = "osmo1zttzenjrnfr8tgrsfyu8kw0eshd8mas7yky43jjtactkhvmtkg2qz769y2"
+
+[pools.position]
+default_token_0_amount = 1000000
+default_token_1_amount = 1000000
+spread = "0.05"
+
+[[pools]]
+pool_id = 2
+base_asset = "uosmo"
+quote_asset = "uatom"
+contract_address = "osmo1zttzenjrnfr8tgrsfyu8kw0eshd8mas7yky43jjtactkhvmtkg2qz769y2"
+strategy = "market_make"
+
+[pools.position]
+default_token_0_amount = 500000
+default_token_1_amount = 500000
+spread = "0.1"
+`
+	path := filepath.Join(t.TempDir(), "config.toml")
+	assert.NilError(t, os.WriteFile(path, []byte(contents), 0o600))
+
+	cfg, err := load(path, nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(cfg.Pools), 2)
+	assert.Equal(t, cfg.Pools[1].QuoteAsset, "uatom")
+	assert.Equal(t, cfg.Pools[1].Position.Spread, "0.1")
+}
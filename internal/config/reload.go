@@ -0,0 +1,58 @@
+package config
+
+import (
+	"reflect"
+	"regexp"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+// Change is a config key whose value differs between two configs
+type Change struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// Diff returns the keys that differ between two configs. Entries added to
+// or removed from an array of tables show up as changes of their keys.
+func Diff(old, new *types.Config) []Change {
+	oldFields := fields(reflect.ValueOf(old).Elem(), "")
+	newFields := fields(reflect.ValueOf(new).Elem(), "")
+
+	newValues := make(map[string]any, len(newFields))
+	for _, f := range newFields {
+		newValues[f.key] = f.value.Interface()
+	}
+
+	var changes []Change
+	seen := make(map[string]bool, len(oldFields))
+
+	for _, f := range oldFields {
+		seen[f.key] = true
+
+		o, n := f.value.Interface(), newValues[f.key]
+		if !reflect.DeepEqual(o, n) {
+			changes = append(changes, Change{Key: f.key, Old: o, New: n})
+		}
+	}
+
+	for _, f := range newFields {
+		if !seen[f.key] {
+			changes = append(changes, Change{Key: f.key, New: f.value.Interface()})
+		}
+	}
+
+	return changes
+}
+
+// reloadableKey matches the keys of the position table, at the top level or
+// of a [[pools]] entry
+var reloadableKey = regexp.MustCompile(`^(pools\.\d+\.)?position\.`)
+
+// Reloadable reports whether a key can be changed without a restart. Only
+// the strategy parameters in the position tables are, since the other keys
+// configure connections, signing and transactions that are set up once.
+func Reloadable(key string) bool {
+	return reloadableKey.MatchString(key)
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old := validConfig()
+	updated := validConfig()
+	updated.Position.Spread = "0.1"
+	updated.RPCServerAddresses = []string{"http://fallback:26657"}
+
+	changes := Diff(&old, &updated)
+	assert.DeepEqual(t, changes, []Change{
+		{Key: "rpc_server_addresses", Old: []string(nil), New: []string{"http://fallback:26657"}},
+		{Key: "position.spread", Old: "0.05", New: "0.1"},
+	})
+
+	assert.Equal(t, len(Diff(&old, &old)), 0)
+}
+
+func TestDiffPools(t *testing.T) {
+	old := poolsConfig()
+	updated := poolsConfig()
+	updated.Pools[1].Position.Spread = "0.2"
+	updated.Pools = append(updated.Pools, updated.Pools[1])
+
+	var keys []string
+	for _, change := range Diff(&old, &updated) {
+		keys = append(keys, change.Key)
+	}
+
+	assert.Assert(t, len(keys) > 1)
+	assert.Equal(t, keys[0], "pools.1.position.spread")
+	assert.Equal(t, keys[1], "pools.2.pool_id")
+}
+
+func TestReloadable(t *testing.T) {
+	assert.Assert(t, Reloadable("position.spread"))
+	assert.Assert(t, Reloadable("position.default_token_0_amount"))
+	assert.Assert(t, !Reloadable("rpc_server_address"))
+	assert.Assert(t, !Reloadable("power_pool.contract_address"))
+	assert.Assert(t, !Reloadable("strategy"))
+	assert.Assert(t, Reloadable("pools.1.position.spread"))
+	assert.Assert(t, !Reloadable("pools.1.contract_address"))
+}
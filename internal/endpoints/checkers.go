@@ -0,0 +1,60 @@
+package endpoints
+
+import (
+	"context"
+	"fmt"
+
+	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// RPCChecker reads the latest block height from a CometBFT RPC endpoint and
+// treats nodes that are still catching up as unhealthy
+func RPCChecker(ctx context.Context, address string) (int64, error) {
+	client, err := rpchttp.New(address, "/websocket")
+	if err != nil {
+		return 0, err
+	}
+
+	status, err := client.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if status.SyncInfo.CatchingUp {
+		return 0, fmt.Errorf("node is catching up at height %d", status.SyncInfo.LatestBlockHeight)
+	}
+
+	return status.SyncInfo.LatestBlockHeight, nil
+}
+
+// GRPCChecker reads the latest block height from a gRPC endpoint
+func GRPCChecker(ctx context.Context, address string) (int64, error) {
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	return LatestHeight(ctx, conn)
+}
+
+// LatestHeight reads the latest block height over a gRPC connection
+func LatestHeight(ctx context.Context, conn *grpc.ClientConn) (int64, error) {
+	res, err := tmservice.NewServiceClient(conn).GetLatestBlock(ctx, &tmservice.GetLatestBlockRequest{})
+	if err != nil {
+		return 0, err
+	}
+
+	if res.SdkBlock != nil {
+		return res.SdkBlock.Header.Height, nil
+	}
+
+	if res.Block != nil {
+		return res.Block.Header.Height, nil
+	}
+
+	return 0, fmt.Errorf("no block returned")
+}
@@ -0,0 +1,168 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+// retireGracePeriod is how long the connection of replaced clients stays
+// open, so that queries and transactions already using it can finish. It
+// covers a rebalance including the wait for its transaction to confirm.
+const retireGracePeriod = 5 * time.Minute
+
+// Builder creates the chain clients for an RPC and a gRPC address
+type Builder func(ctx context.Context, rpcAddress, grpcAddress string) (types.BlockchainClients, error)
+
+// Clients holds chain clients connected to the best RPC and gRPC endpoints,
+// rebuilding them whenever a different endpoint is selected.
+type Clients struct {
+	l     *zap.Logger
+	rpc   *Pool
+	grpc  *Pool
+	build Builder
+
+	mu          sync.Mutex
+	rpcAddress  string
+	grpcAddress string
+	current     types.BlockchainClients
+
+	// retired holds the connections of replaced clients until they are
+	// closed after the grace period
+	gracePeriod time.Duration
+	retired     map[*grpc.ClientConn]*time.Timer
+}
+
+// NewClients returns a client set that fails over between the endpoints of
+// the rpc and grpc pools
+func NewClients(l *zap.Logger, rpc, grpc *Pool, build Builder) *Clients {
+	return &Clients{
+		l:           l,
+		rpc:         rpc,
+		grpc:        grpc,
+		build:       build,
+		gracePeriod: retireGracePeriod,
+	}
+}
+
+// Get returns the clients for the currently best endpoints. Clients
+// returned before a failover stay usable for a grace period.
+func (c *Clients) Get(ctx context.Context) (types.BlockchainClients, error) {
+	rpcAddress, err := c.rpc.Best()
+	if err != nil {
+		return types.BlockchainClients{}, err
+	}
+
+	grpcAddress, err := c.grpc.Best()
+	if err != nil {
+		return types.BlockchainClients{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rpcAddress == c.rpcAddress && grpcAddress == c.grpcAddress {
+		return c.current, nil
+	}
+
+	clients, err := c.build(ctx, rpcAddress, grpcAddress)
+	if err != nil {
+		return types.BlockchainClients{}, err
+	}
+
+	if c.rpcAddress != "" {
+		c.l.Warn("Failing over to new endpoints",
+			zap.String("rpc_address", rpcAddress),
+			zap.String("grpc_address", grpcAddress),
+			zap.String("previous_rpc_address", c.rpcAddress),
+			zap.String("previous_grpc_address", c.grpcAddress),
+		)
+	}
+
+	c.retire(c.current.GRPCClient)
+	c.rpcAddress, c.grpcAddress, c.current = rpcAddress, grpcAddress, clients
+
+	return clients, nil
+}
+
+// Report marks the endpoint an error came from as down when the error shows
+// it could not be reached, so that the next Get fails over without waiting
+// for the next health check. gRPC errors come from queries and network
+// errors outside of gRPC from transactions sent through the RPC endpoint.
+func (c *Clients) Report(err error) {
+	if err == nil {
+		return
+	}
+
+	c.mu.Lock()
+	rpcAddress, grpcAddress := c.rpcAddress, c.grpcAddress
+	c.mu.Unlock()
+
+	if s, ok := status.FromError(err); ok {
+		if s.Code() == codes.Unavailable && grpcAddress != "" {
+			c.grpc.MarkDown(grpcAddress, err)
+		}
+		return
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && rpcAddress != "" {
+		c.rpc.MarkDown(rpcAddress, err)
+	}
+}
+
+// Close closes the connections of the current and replaced clients
+func (c *Clients) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for conn, timer := range c.retired {
+		timer.Stop()
+		c.closeConn(conn)
+	}
+	c.retired = nil
+
+	c.closeConn(c.current.GRPCClient)
+	c.rpcAddress, c.grpcAddress, c.current = "", "", types.BlockchainClients{}
+}
+
+// retire closes the connection of replaced clients once the grace period
+// has passed. It must be called with the lock held.
+func (c *Clients) retire(conn *grpc.ClientConn) {
+	if conn == nil {
+		return
+	}
+
+	if c.retired == nil {
+		c.retired = make(map[*grpc.ClientConn]*time.Timer)
+	}
+	c.retired[conn] = time.AfterFunc(c.gracePeriod, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if _, ok := c.retired[conn]; !ok {
+			return
+		}
+		delete(c.retired, conn)
+		c.closeConn(conn)
+	})
+}
+
+func (c *Clients) closeConn(conn *grpc.ClientConn) {
+	if conn == nil {
+		return
+	}
+
+	if err := conn.Close(); err != nil {
+		c.l.Debug("Failed to close gRPC connection", zap.Error(err))
+	}
+}
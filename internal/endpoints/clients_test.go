@@ -0,0 +1,102 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+func TestClientsKeepReplacedConnectionOpen(t *testing.T) {
+	logger := zap.NewNop()
+
+	healthy := map[string]bool{"a": true, "b": true}
+	check := func(_ context.Context, address string) (int64, error) {
+		if !healthy[address] {
+			return 0, context.DeadlineExceeded
+		}
+		return 100, nil
+	}
+
+	rpc := NewPool(logger, "rpc", []string{"a", "b"}, 5, 0, check)
+	grpcPool := NewPool(logger, "grpc", []string{"a", "b"}, 5, 0, check)
+	rpc.Check(context.Background())
+	grpcPool.Check(context.Background())
+
+	build := func(_ context.Context, _, grpcAddress string) (types.BlockchainClients, error) {
+		conn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		return types.BlockchainClients{GRPCClient: conn}, err
+	}
+
+	clients := NewClients(logger, rpc, grpcPool, build)
+	clients.gracePeriod = 50 * time.Millisecond
+
+	first, err := clients.Get(context.Background())
+	assert.NilError(t, err)
+
+	healthy["a"] = false
+	rpc.Check(context.Background())
+	grpcPool.Check(context.Background())
+
+	second, err := clients.Get(context.Background())
+	assert.NilError(t, err)
+	assert.Assert(t, second.GRPCClient != first.GRPCClient)
+
+	// The replaced connection stays open for callers still using it
+	assert.Assert(t, first.GRPCClient.GetState() != connectivity.Shutdown)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, first.GRPCClient.GetState(), connectivity.Shutdown)
+	assert.Assert(t, second.GRPCClient.GetState() != connectivity.Shutdown)
+
+	clients.Close()
+	assert.Equal(t, second.GRPCClient.GetState(), connectivity.Shutdown)
+}
+
+func TestClientsReportFailsOver(t *testing.T) {
+	logger := zap.NewNop()
+
+	check := func(_ context.Context, _ string) (int64, error) {
+		return 100, nil
+	}
+
+	rpc := NewPool(logger, "rpc", []string{"rpc-a", "rpc-b"}, 5, 0, check)
+	grpcPool := NewPool(logger, "grpc", []string{"grpc-a", "grpc-b"}, 5, 0, check)
+	rpc.Check(context.Background())
+	grpcPool.Check(context.Background())
+
+	build := func(_ context.Context, _, _ string) (types.BlockchainClients, error) {
+		return types.BlockchainClients{}, nil
+	}
+
+	clients := NewClients(logger, rpc, grpcPool, build)
+	_, err := clients.Get(context.Background())
+	assert.NilError(t, err)
+
+	// Errors that do not show an unreachable endpoint are ignored
+	clients.Report(status.Error(codes.InvalidArgument, "bad request"))
+	assert.Equal(t, clients.grpcAddress, "grpc-a")
+
+	clients.Report(fmt.Errorf("getting pool: %w", status.Error(codes.Unavailable, "connection refused")))
+	_, err = clients.Get(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, clients.grpcAddress, "grpc-b")
+	assert.Equal(t, clients.rpcAddress, "rpc-a")
+
+	clients.Report(fmt.Errorf("broadcasting transaction: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	_, err = clients.Get(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, clients.rpcAddress, "rpc-b")
+}
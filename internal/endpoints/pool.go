@@ -0,0 +1,194 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxHeightLag = int64(5)
+	defaultMaxLatency   = 5 * time.Second
+	checkTimeout        = 10 * time.Second
+)
+
+// Checker returns the latest block height known to an endpoint
+type Checker func(ctx context.Context, address string) (int64, error)
+
+// Status is the result of the latest health check of an endpoint
+type Status struct {
+	Address string
+	Height  int64
+	Latency time.Duration
+	Err     error
+}
+
+// Pool health checks a list of endpoints and selects the best one to use.
+type Pool struct {
+	l            *zap.Logger
+	name         string
+	addresses    []string
+	maxHeightLag int64
+	maxLatency   time.Duration
+	check        Checker
+
+	mu       sync.RWMutex
+	statuses []Status
+
+	// down holds the endpoints that failed a query or broadcast since the
+	// last health check
+	down map[string]bool
+}
+
+// NewPool returns a pool for the addresses, which are listed in order of
+// preference. Endpoints lagging more than maxHeightLag blocks behind the best
+// known height, or answering the health check slower than maxLatency, are
+// skipped.
+func NewPool(l *zap.Logger, name string, addresses []string, maxHeightLag int64, maxLatency time.Duration, check Checker) *Pool {
+	if maxHeightLag <= 0 {
+		maxHeightLag = defaultMaxHeightLag
+	}
+
+	if maxLatency <= 0 {
+		maxLatency = defaultMaxLatency
+	}
+
+	statuses := make([]Status, len(addresses))
+	for i, address := range addresses {
+		statuses[i] = Status{Address: address, Err: errors.New("not checked")}
+	}
+
+	return &Pool{
+		l:            l.With(zap.String("endpoints", name)),
+		name:         name,
+		addresses:    addresses,
+		maxHeightLag: maxHeightLag,
+		maxLatency:   maxLatency,
+		check:        check,
+		statuses:     statuses,
+	}
+}
+
+// Check health checks every endpoint concurrently
+func (p *Pool) Check(ctx context.Context) {
+	statuses := make([]Status, len(p.addresses))
+
+	var wg sync.WaitGroup
+	for i, address := range p.addresses {
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			height, err := p.check(checkCtx, address)
+			latency := time.Since(start)
+			if err == nil && latency > p.maxLatency {
+				err = fmt.Errorf("latency %s exceeds %s", latency, p.maxLatency)
+			}
+
+			statuses[i] = Status{
+				Address: address,
+				Height:  height,
+				Latency: latency,
+				Err:     err,
+			}
+		}(i, address)
+	}
+	wg.Wait()
+
+	for _, s := range statuses {
+		if s.Err != nil {
+			p.l.Warn("Endpoint health check failed", zap.String("address", s.Address), zap.Error(s.Err))
+			continue
+		}
+		p.l.Debug("Endpoint health check",
+			zap.String("address", s.Address),
+			zap.Int64("height", s.Height),
+			zap.Duration("latency", s.Latency),
+		)
+	}
+
+	p.mu.Lock()
+	p.statuses = statuses
+	p.down = nil
+	p.mu.Unlock()
+}
+
+// MarkDown skips an endpoint that failed a query or broadcast until the next
+// health check, so that the next call to Best fails over
+func (p *Pool) MarkDown(address string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.down == nil {
+		p.down = make(map[string]bool)
+	}
+	p.down[address] = true
+
+	p.l.Warn("Endpoint failed, skipping it until the next health check", zap.String("address", address), zap.Error(err))
+}
+
+// Run health checks the endpoints every interval until the context is done
+func (p *Pool) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Check(ctx)
+		}
+	}
+}
+
+// Best returns the first endpoint in order of preference that is healthy
+// and within maxHeightLag of the best known height. Following the configured
+// order rather than latency keeps the choice stable between health checks.
+// Endpoints marked down are only returned when no other endpoint is healthy.
+func (p *Pool) Best() (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var maxHeight int64
+	for _, s := range p.statuses {
+		if s.Err == nil && s.Height > maxHeight {
+			maxHeight = s.Height
+		}
+	}
+
+	var fallback string
+	for _, s := range p.statuses {
+		if s.Err != nil || s.Height < maxHeight-p.maxHeightLag {
+			continue
+		}
+		if !p.down[s.Address] {
+			return s.Address, nil
+		}
+		if fallback == "" {
+			fallback = s.Address
+		}
+	}
+
+	if fallback != "" {
+		return fallback, nil
+	}
+
+	return "", fmt.Errorf("no healthy %s endpoint", p.name)
+}
+
+// Statuses returns the results of the latest health check
+func (p *Pool) Statuses() []Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]Status(nil), p.statuses...)
+}
@@ -0,0 +1,106 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gotest.tools/assert"
+)
+
+func TestPoolBestSkipsUnhealthyAndLagging(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	heights := map[string]int64{
+		"fast-lagging": 90,
+		"slow":         100,
+		"down":         0,
+	}
+
+	check := func(_ context.Context, address string) (int64, error) {
+		switch address {
+		case "down":
+			return 0, errors.New("connection refused")
+		case "slow":
+			time.Sleep(20 * time.Millisecond)
+		}
+		return heights[address], nil
+	}
+
+	pool := NewPool(logger, "rpc", []string{"down", "fast-lagging", "slow"}, 5, 0, check)
+
+	_, err := pool.Best()
+	assert.ErrorContains(t, err, "no healthy rpc endpoint")
+
+	pool.Check(context.Background())
+
+	best, err := pool.Best()
+	assert.NilError(t, err)
+	assert.Equal(t, "slow", best)
+}
+
+func TestPoolBestFollowsOrderOfPreference(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	check := func(_ context.Context, address string) (int64, error) {
+		if address == "slow" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return 100, nil
+	}
+
+	pool := NewPool(logger, "grpc", []string{"slow", "fast"}, 5, 0, check)
+	pool.Check(context.Background())
+
+	best, err := pool.Best()
+	assert.NilError(t, err)
+	assert.Equal(t, "slow", best)
+}
+
+func TestPoolBestSkipsSlowEndpoints(t *testing.T) {
+	logger := zap.NewNop()
+
+	check := func(_ context.Context, address string) (int64, error) {
+		if address == "slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return 100, nil
+	}
+
+	pool := NewPool(logger, "grpc", []string{"slow", "fast"}, 5, 20*time.Millisecond, check)
+	pool.Check(context.Background())
+
+	best, err := pool.Best()
+	assert.NilError(t, err)
+	assert.Equal(t, "fast", best)
+}
+
+func TestPoolMarkDown(t *testing.T) {
+	logger := zap.NewNop()
+
+	check := func(_ context.Context, _ string) (int64, error) {
+		return 100, nil
+	}
+
+	pool := NewPool(logger, "rpc", []string{"a", "b"}, 5, 0, check)
+	pool.Check(context.Background())
+
+	pool.MarkDown("a", errors.New("connection refused"))
+	best, err := pool.Best()
+	assert.NilError(t, err)
+	assert.Equal(t, "b", best)
+
+	// An endpoint marked down is still used when no other one is healthy
+	pool.MarkDown("b", errors.New("connection refused"))
+	best, err = pool.Best()
+	assert.NilError(t, err)
+	assert.Equal(t, "a", best)
+
+	// The next health check clears the marks
+	pool.Check(context.Background())
+	best, err = pool.Best()
+	assert.NilError(t, err)
+	assert.Equal(t, "a", best)
+}
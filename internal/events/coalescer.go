@@ -0,0 +1,101 @@
+package events
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+// heightKey is the event attribute holding the height of the transaction
+const heightKey = "tx.height"
+
+// Height returns the block height of a subscription event, or 0 if it has
+// none
+func Height(result ctypes.ResultEvent) int64 {
+	values := result.Events[heightKey]
+	if len(values) == 0 {
+		return 0
+	}
+
+	height, err := strconv.ParseInt(values[len(values)-1], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return height
+}
+
+// Merge collapses an event into a later one. The result carries the later
+// event at the highest height seen, the time the earlier one was received
+// and the number of events both represent. It is periodic if either is.
+func Merge(earlier, later Event) Event {
+	merged := later
+	merged.ReceivedAt = earlier.ReceivedAt
+	merged.Count = earlier.Count + later.Count
+	merged.Periodic = earlier.Periodic || later.Periodic
+	if earlier.Height > merged.Height {
+		merged.Height = earlier.Height
+	}
+	return merged
+}
+
+// Coalescer collapses the events that arrive within a window of the first one
+// into a single event. All transactions of a block are delivered together, so
+// a window shorter than the block time collapses the events of a block.
+type Coalescer struct {
+	window  time.Duration
+	deliver func(Event)
+
+	mu      sync.Mutex
+	pending *Event
+	timer   *time.Timer
+}
+
+// NewCoalescer returns a coalescer that hands each collapsed event to deliver
+// once the window has passed
+func NewCoalescer(window time.Duration, deliver func(Event)) *Coalescer {
+	return &Coalescer{
+		window:  window,
+		deliver: deliver,
+	}
+}
+
+// Add adds an event, starting the window if none is open
+func (c *Coalescer) Add(event Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pending != nil {
+		merged := Merge(*c.pending, event)
+		c.pending = &merged
+		return
+	}
+
+	c.pending = &event
+	c.timer = time.AfterFunc(c.window, c.flush)
+}
+
+// Stop discards the pending event
+func (c *Coalescer) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.pending = nil
+}
+
+func (c *Coalescer) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pending == nil {
+		return
+	}
+
+	c.deliver(*c.pending)
+	c.pending = nil
+}
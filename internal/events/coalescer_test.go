@@ -0,0 +1,90 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"gotest.tools/assert"
+)
+
+func swap(height string) Event {
+	result := ctypes.ResultEvent{
+		Query:  "token_swapped.pool_id = '1'",
+		Events: map[string][]string{"tx.height": {height}},
+	}
+	return Event{Result: result, ReceivedAt: time.Now(), Height: Height(result), Count: 1}
+}
+
+func TestHeight(t *testing.T) {
+	assert.Equal(t, Height(swap("12345").Result), int64(12345))
+	assert.Equal(t, Height(ctypes.ResultEvent{Query: CatchUpQuery}), int64(0))
+	assert.Equal(t, Height(swap("abc").Result), int64(0))
+}
+
+func TestMerge(t *testing.T) {
+	first := swap("10")
+	second := swap("11")
+
+	merged := Merge(first, second)
+	assert.Equal(t, merged.Height, int64(11))
+	assert.Equal(t, merged.Count, 2)
+	assert.Equal(t, merged.ReceivedAt, first.ReceivedAt)
+
+	// A catch-up event has no height, so the height seen earlier is kept
+	merged = Merge(merged, Event{Result: ctypes.ResultEvent{Query: CatchUpQuery}, Count: 1})
+	assert.Equal(t, merged.Height, int64(11))
+	assert.Equal(t, merged.Count, 3)
+	assert.Assert(t, !merged.Periodic)
+
+	// A swap collapsed with a timer event is still periodic
+	merged = Merge(Event{Result: ctypes.ResultEvent{Query: TimerQuery}, Count: 1, Periodic: true}, swap("12"))
+	assert.Equal(t, merged.Result.Query, swap("12").Result.Query)
+	assert.Assert(t, merged.Periodic)
+}
+
+func TestCoalescer(t *testing.T) {
+	delivered := make(chan Event, 10)
+	c := NewCoalescer(50*time.Millisecond, func(e Event) { delivered <- e })
+	defer c.Stop()
+
+	first := swap("10")
+	for i := 0; i < 20; i++ {
+		c.Add(first)
+	}
+	c.Add(swap("11"))
+
+	select {
+	case e := <-delivered:
+		assert.Equal(t, e.Count, 21)
+		assert.Equal(t, e.Height, int64(11))
+		assert.Equal(t, e.ReceivedAt, first.ReceivedAt)
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+	}
+
+	// Events after the window open a new one
+	c.Add(swap("12"))
+
+	select {
+	case e := <-delivered:
+		assert.Equal(t, e.Count, 1)
+		assert.Equal(t, e.Height, int64(12))
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+	}
+}
+
+func TestCoalescerStop(t *testing.T) {
+	delivered := make(chan Event, 1)
+	c := NewCoalescer(10*time.Millisecond, func(e Event) { delivered <- e })
+
+	c.Add(swap("10"))
+	c.Stop()
+
+	select {
+	case <-delivered:
+		t.Fatal("event delivered after stop")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
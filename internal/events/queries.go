@@ -0,0 +1,80 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/cometbft/cometbft/libs/pubsub/query"
+)
+
+// DefaultSwapQueries match every swap in a pool. Swaps in concentrated
+// liquidity and gamm pools, including each hop of a poolmanager route, are
+// emitted as token_swapped events by poolmanager. The module attribute of
+// those events has changed between Osmosis versions, so the default filters
+// on the pool id alone, which poolmanager assigns uniquely across modules.
+var DefaultSwapQueries = []string{
+	"token_swapped.pool_id = '{{.PoolID}}'",
+}
+
+// QueryData holds the values available to event query templates, for
+// example "token_swapped.module = 'concentratedliquidity' AND
+// token_swapped.pool_id = '{{.PoolID}}'"
+type QueryData struct {
+	PoolID uint64
+}
+
+// SwapQueries renders the query templates for each of the pools, returning
+// the distinct queries in order. No templates fall back to the default swap
+// queries.
+func SwapQueries(templates []string, poolIDs ...uint64) ([]string, error) {
+	if len(templates) == 0 {
+		templates = DefaultSwapQueries
+	}
+
+	var queries []string
+	seen := make(map[string]bool)
+
+	for _, text := range templates {
+		tmpl, err := template.New("query").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing query template %q: %w", text, err)
+		}
+
+		for _, poolID := range poolIDs {
+			var rendered strings.Builder
+			if err := tmpl.Execute(&rendered, QueryData{PoolID: poolID}); err != nil {
+				return nil, fmt.Errorf("rendering query template %q: %w", text, err)
+			}
+
+			q := rendered.String()
+			if _, err := query.New(q); err != nil {
+				return nil, fmt.Errorf("invalid query %q: %w", q, err)
+			}
+
+			if !seen[q] {
+				seen[q] = true
+				queries = append(queries, q)
+			}
+		}
+	}
+
+	return queries, nil
+}
+
+// MergeQueries returns the distinct queries of several sets in order
+func MergeQueries(sets ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+
+	for _, set := range sets {
+		for _, q := range set {
+			if !seen[q] {
+				seen[q] = true
+				merged = append(merged, q)
+			}
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,38 @@
+package events
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSwapQueries(t *testing.T) {
+	queries, err := SwapQueries(nil, 1, 2)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, queries, []string{"token_swapped.pool_id = '1'", "token_swapped.pool_id = '2'"})
+
+	queries, err = SwapQueries([]string{
+		"token_swapped.module = 'concentratedliquidity' AND token_swapped.pool_id = '{{.PoolID}}'",
+		"token_swapped.pool_id = '{{.PoolID}}'",
+		"token_swapped.pool_id = '{{.PoolID}}'",
+	}, 1)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, queries, []string{
+		"token_swapped.module = 'concentratedliquidity' AND token_swapped.pool_id = '1'",
+		"token_swapped.pool_id = '1'",
+	})
+
+	_, err = SwapQueries([]string{"token_swapped.pool_id = '{{.Pool}}'"}, 1)
+	assert.ErrorContains(t, err, "rendering query template")
+
+	_, err = SwapQueries([]string{"token_swapped.pool_id = '{{.PoolID}'"}, 1)
+	assert.ErrorContains(t, err, "parsing query template")
+
+	_, err = SwapQueries([]string{"token_swapped.pool_id == '{{.PoolID}}'"}, 1)
+	assert.ErrorContains(t, err, "invalid query")
+}
+
+func TestMergeQueries(t *testing.T) {
+	merged := MergeQueries([]string{"a", "b"}, []string{"b", "c"})
+	assert.DeepEqual(t, merged, []string{"a", "b", "c"})
+}
@@ -0,0 +1,391 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/metrics"
+)
+
+const (
+	// CatchUpQuery marks the synthetic event emitted after a reconnect, so
+	// that swaps missed while disconnected are accounted for
+	CatchUpQuery = "catch_up"
+
+	// TimerQuery marks the synthetic event emitted every rebalance interval
+	TimerQuery = "timer"
+
+	// BlockIntervalQuery marks the synthetic event emitted every rebalance
+	// interval of blocks
+	BlockIntervalQuery = "block_interval"
+
+	// newBlockHeaderQuery is used to detect a stale subscription
+	newBlockHeaderQuery = "tm.event = 'NewBlockHeader'"
+
+	// subscriber is an arbitrary string identifying the subscription
+	subscriber = "flood"
+
+	// eventBuffer is the number of events held for the consumer, which is
+	// expected to hand them off to per-pool workers promptly
+	eventBuffer = 64
+
+	// defaultMaxSubscriptions is the default max_subscriptions_per_client of
+	// CometBFT nodes
+	defaultMaxSubscriptions = 5
+
+	defaultStaleTimeout = 30 * time.Second
+	defaultMaxBackoff   = time.Minute
+	minBackoff          = time.Second
+)
+
+var errStale = errors.New("no new block header received")
+
+// Event is a subscription event together with the time it was received. An
+// event collapsed from several carries the latest of them, the time the first
+// was received and how many there were. Periodic is set for events of the
+// timer and block interval triggers, and for events collapsed with one.
+type Event struct {
+	Result     ctypes.ResultEvent
+	ReceivedAt time.Time
+	Height     int64
+	Count      int
+	Periodic   bool
+}
+
+// Supervisor keeps the subscriptions to a set of queries alive. Nodes limit
+// the subscriptions of a websocket, so the queries are spread over as many
+// websockets to the same endpoint as needed. It reconnects with exponential
+// backoff when a connection drops or when no new block header has been seen
+// for the stale timeout, resubscribes, and emits a catch-up event after every
+// reconnect.
+type Supervisor struct {
+	l                *zap.Logger
+	address          func() (string, error)
+	websocketPath    string
+	queries          []string
+	maxSubscriptions int
+	staleTimeout     time.Duration
+	maxBackoff       time.Duration
+	metrics          *metrics.Metrics
+
+	// rebalanceInterval and rebalanceBlocks set how often periodic
+	// rebalances are triggered, zero disabling them
+	rebalanceInterval time.Duration
+	rebalanceBlocks   int64
+
+	events chan Event
+
+	// lastEvent and lastHeader hold the unix nano times the last event and
+	// block header were received
+	lastEvent  atomic.Int64
+	lastHeader atomic.Int64
+}
+
+// NewSupervisor returns a supervisor for the queries, subscribing to at most
+// maxSubscriptions of them per websocket. The address function is called on
+// every (re)connect to pick the RPC endpoint. Zero values fall back to the
+// defaults.
+func NewSupervisor(l *zap.Logger, address func() (string, error), websocketPath string, queries []string, maxSubscriptions int, staleTimeout, maxBackoff time.Duration, m *metrics.Metrics) *Supervisor {
+	if maxSubscriptions <= 0 {
+		maxSubscriptions = defaultMaxSubscriptions
+	}
+	if staleTimeout <= 0 {
+		staleTimeout = defaultStaleTimeout
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	return &Supervisor{
+		l:                l,
+		address:          address,
+		websocketPath:    websocketPath,
+		queries:          queries,
+		maxSubscriptions: maxSubscriptions,
+		staleTimeout:     staleTimeout,
+		maxBackoff:       maxBackoff,
+		metrics:          m,
+		events:           make(chan Event, eventBuffer),
+	}
+}
+
+// Events returns the channel the subscribed events are delivered on. The
+// query of an event identifies the subscription it came from. Events are
+// dropped rather than blocking the subscription if the buffer is full.
+func (s *Supervisor) Events() <-chan Event {
+	return s.events
+}
+
+// RebalanceEvery makes the supervisor emit an event every interval and every
+// number of blocks, so that pools are repriced without swaps. A zero interval
+// or number of blocks disables that trigger. It must be called before Run.
+func (s *Supervisor) RebalanceEvery(interval time.Duration, blocks int64) {
+	s.rebalanceInterval = interval
+	s.rebalanceBlocks = blocks
+}
+
+// Synthetic reports whether an event query marks an event emitted by the
+// supervisor rather than a subscription. Synthetic events concern every pool.
+func Synthetic(query string) bool {
+	return query == CatchUpQuery || query == TimerQuery || query == BlockIntervalQuery
+}
+
+// LastEvent returns the time the last event was received, or the zero time
+func (s *Supervisor) LastEvent() time.Time {
+	return unixNano(s.lastEvent.Load())
+}
+
+// LastHeader returns the time the last block header was received, or the
+// zero time
+func (s *Supervisor) LastHeader() time.Time {
+	return unixNano(s.lastHeader.Load())
+}
+
+// Run connects and keeps reconnecting until the context is cancelled
+func (s *Supervisor) Run(ctx context.Context) {
+	if s.rebalanceInterval > 0 {
+		go s.tick(ctx)
+	}
+
+	backoff := minBackoff
+	connected := false
+
+	for {
+		err := s.session(ctx, connected, func() {
+			connected = true
+			backoff = minBackoff
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.l.Warn("Websocket subscription lost, reconnecting",
+			zap.Error(err),
+			zap.Duration("backoff", backoff),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// session runs the websocket connections until one fails or the context is
+// cancelled. onSubscribed is called once the subscriptions are in place.
+func (s *Supervisor) session(ctx context.Context, reconnect bool, onSubscribed func()) error {
+	address, err := s.address()
+	if err != nil {
+		return err
+	}
+
+	// Merge the subscriptions into a single channel until the session ends
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	eventCh := make(chan ctypes.ResultEvent)
+	closedCh := make(chan string, len(s.queries))
+
+	var clients []*rpchttp.HTTP
+	defer func() {
+		for _, client := range clients {
+			if err := client.Stop(); err != nil {
+				s.l.Debug("Failed to stop websocket client", zap.Error(err))
+			}
+		}
+	}()
+
+	var headerCh <-chan ctypes.ResultEvent
+	for _, batch := range batchQueries(append([]string{newBlockHeaderQuery}, s.queries...), s.maxSubscriptions) {
+		client, err := rpchttp.New(address, s.websocketPath)
+		if err != nil {
+			return fmt.Errorf("creating websocket client: %w", err)
+		}
+
+		if err := client.Start(); err != nil {
+			return fmt.Errorf("starting websocket client: %w", err)
+		}
+		clients = append(clients, client)
+
+		for _, query := range batch {
+			//nolint:staticcheck
+			ch, err := client.Subscribe(ctx, subscriber, query)
+			if err != nil {
+				return fmt.Errorf("subscribing to %q: %w", query, err)
+			}
+
+			if query == newBlockHeaderQuery {
+				headerCh = ch
+				continue
+			}
+
+			go forward(sessionCtx, query, ch, eventCh, closedCh)
+		}
+	}
+
+	s.l.Info("Subscribed to events",
+		zap.String("address", address),
+		zap.Strings("queries", s.queries),
+		zap.Int("websockets", len(clients)),
+	)
+
+	onSubscribed()
+
+	if reconnect {
+		s.emit(ctypes.ResultEvent{Query: CatchUpQuery})
+	}
+
+	stale := time.NewTimer(s.staleTimeout)
+	defer stale.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			unsubscribeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			for _, client := range clients {
+				//nolint:staticcheck
+				if err := client.UnsubscribeAll(unsubscribeCtx, subscriber); err != nil {
+					s.l.Debug("Failed to unsubscribe", zap.Error(err))
+				}
+			}
+			return ctx.Err()
+		case event := <-eventCh:
+			s.emit(event)
+		case query := <-closedCh:
+			return fmt.Errorf("subscription to %q closed", query)
+		case header, ok := <-headerCh:
+			if !ok {
+				return errors.New("block header subscription closed")
+			}
+			s.lastHeader.Store(time.Now().UnixNano())
+			s.onHeader(header)
+			if !stale.Stop() {
+				<-stale.C
+			}
+			stale.Reset(s.staleTimeout)
+		case <-stale.C:
+			return errStale
+		}
+	}
+}
+
+// tick emits a timer event every rebalance interval until the context is
+// done
+func (s *Supervisor) tick(ctx context.Context) {
+	ticker := time.NewTicker(s.rebalanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.deliver(Event{
+				Result:     ctypes.ResultEvent{Query: TimerQuery},
+				ReceivedAt: time.Now(),
+				Count:      1,
+				Periodic:   true,
+			})
+		}
+	}
+}
+
+// onHeader emits a block interval event for every block whose height is a
+// multiple of the rebalance interval of blocks
+func (s *Supervisor) onHeader(header ctypes.ResultEvent) {
+	if s.rebalanceBlocks <= 0 {
+		return
+	}
+
+	data, ok := header.Data.(cmttypes.EventDataNewBlockHeader)
+	if !ok || data.Header.Height%s.rebalanceBlocks != 0 {
+		return
+	}
+
+	event := Event{
+		Result:     ctypes.ResultEvent{Query: BlockIntervalQuery},
+		ReceivedAt: time.Now(),
+		Height:     data.Header.Height,
+		Count:      1,
+		Periodic:   true,
+	}
+	s.deliver(event)
+}
+
+// emit delivers an event without blocking the subscription
+func (s *Supervisor) emit(result ctypes.ResultEvent) {
+	s.deliver(Event{Result: result, ReceivedAt: time.Now(), Height: Height(result), Count: 1})
+}
+
+// deliver queues an event for the bots. Only events of the subscriptions
+// count as received, so that synthetic events do not hide a subscription
+// that has gone quiet.
+func (s *Supervisor) deliver(event Event) {
+	if Synthetic(event.Result.Query) {
+		s.metrics.SyntheticEvent(event.Result.Query)
+	} else {
+		s.metrics.EventReceived(event.Result.Query)
+		s.lastEvent.Store(event.ReceivedAt.UnixNano())
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		s.l.Warn("Event buffer full, dropping event", zap.String("query", event.Result.Query))
+	}
+}
+
+// batchQueries splits the queries into batches of at most size, one per
+// websocket
+func batchQueries(queries []string, size int) [][]string {
+	var batches [][]string
+	for len(queries) > size {
+		batches = append(batches, queries[:size:size])
+		queries = queries[size:]
+	}
+	return append(batches, queries)
+}
+
+// forward passes the events of a subscription on to out until the context is
+// done, reporting the query on closed if the subscription closes first
+func forward(ctx context.Context, query string, in <-chan ctypes.ResultEvent, out chan<- ctypes.ResultEvent, closed chan<- string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-in:
+			if !ok {
+				closed <- query
+				return
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func unixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
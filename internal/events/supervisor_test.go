@@ -0,0 +1,92 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"go.uber.org/zap"
+	"gotest.tools/assert"
+
+	"github.com/margined-protocol/flood/internal/metrics"
+)
+
+func newTestSupervisor() *Supervisor {
+	return NewSupervisor(zap.NewNop(), nil, "/websocket", nil, 0, 0, 0, metrics.New())
+}
+
+func header(height int64) ctypes.ResultEvent {
+	return ctypes.ResultEvent{
+		Query: newBlockHeaderQuery,
+		Data:  cmttypes.EventDataNewBlockHeader{Header: cmttypes.Header{Height: height}},
+	}
+}
+
+func TestBlockIntervalTrigger(t *testing.T) {
+	s := newTestSupervisor()
+	s.RebalanceEvery(0, 10)
+
+	for height := int64(95); height <= 120; height++ {
+		s.onHeader(header(height))
+	}
+
+	var heights []int64
+	for len(s.events) > 0 {
+		event := <-s.events
+		assert.Equal(t, event.Result.Query, BlockIntervalQuery)
+		assert.Assert(t, event.Periodic)
+		heights = append(heights, event.Height)
+	}
+	assert.DeepEqual(t, heights, []int64{100, 110, 120})
+}
+
+func TestBlockIntervalTriggerDisabled(t *testing.T) {
+	s := newTestSupervisor()
+
+	s.onHeader(header(100))
+	assert.Equal(t, len(s.events), 0)
+}
+
+func TestTimerTrigger(t *testing.T) {
+	s := newTestSupervisor()
+	s.RebalanceEvery(10*time.Millisecond, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go s.tick(ctx)
+
+	select {
+	case event := <-s.Events():
+		assert.Equal(t, event.Result.Query, TimerQuery)
+		assert.Assert(t, event.Periodic)
+		// Timer events do not count as events of the subscriptions
+		assert.Assert(t, s.LastEvent().IsZero())
+	case <-time.After(time.Second):
+		t.Fatal("no timer event")
+	}
+}
+
+func TestBatchQueries(t *testing.T) {
+	queries := []string{"a", "b", "c", "d", "e", "f", "g"}
+
+	assert.DeepEqual(t, batchQueries(queries, 5), [][]string{{"a", "b", "c", "d", "e"}, {"f", "g"}})
+	assert.DeepEqual(t, batchQueries(queries, 7), [][]string{queries})
+	assert.DeepEqual(t, batchQueries(queries[:1], 5), [][]string{{"a"}})
+}
+
+func TestSynthetic(t *testing.T) {
+	assert.Assert(t, Synthetic(CatchUpQuery))
+	assert.Assert(t, Synthetic(TimerQuery))
+	assert.Assert(t, Synthetic(BlockIntervalQuery))
+	assert.Assert(t, !Synthetic("token_swapped.pool_id = '1'"))
+}
+
+func TestSubscriptionEventUpdatesLastEvent(t *testing.T) {
+	s := newTestSupervisor()
+
+	s.emit(ctypes.ResultEvent{Query: "token_swapped.pool_id = '1'"})
+	assert.Assert(t, !s.LastEvent().IsZero())
+}
@@ -0,0 +1,242 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/endpoints"
+	"github.com/margined-protocol/flood/internal/power"
+	"github.com/margined-protocol/flood/internal/queries"
+)
+
+const (
+	defaultStaleTimeout = 5 * time.Minute
+	checkTimeout        = 5 * time.Second
+)
+
+// Subscription reports when the event subscription last received data
+type Subscription interface {
+	LastEvent() time.Time
+	LastHeader() time.Time
+}
+
+// Rebalancer reports when the last rebalance succeeded
+type Rebalancer interface {
+	LastRebalance() time.Time
+}
+
+// Check returns an error when a dependency of the bot is not ready
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker serves the liveness and readiness of the bot
+type Checker struct {
+	l            *zap.Logger
+	staleTimeout time.Duration
+	startedAt    time.Time
+	subscription Subscription
+	rebalancer   Rebalancer
+	checks       []namedCheck
+}
+
+// CheckResult is the result of a single check
+type CheckResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the body of the health endpoints
+type Report struct {
+	Status        string                 `json:"status"`
+	LastEvent     *time.Time             `json:"last_event,omitempty"`
+	LastHeader    *time.Time             `json:"last_header,omitempty"`
+	LastRebalance *time.Time             `json:"last_rebalance,omitempty"`
+	Checks        map[string]CheckResult `json:"checks"`
+}
+
+// NewChecker returns a checker that considers the subscription stale when no
+// block header has arrived within the stale timeout
+func NewChecker(l *zap.Logger, staleTimeout time.Duration, subscription Subscription, rebalancer Rebalancer) *Checker {
+	if staleTimeout <= 0 {
+		staleTimeout = defaultStaleTimeout
+	}
+
+	return &Checker{
+		l:            l,
+		staleTimeout: staleTimeout,
+		startedAt:    time.Now(),
+		subscription: subscription,
+		rebalancer:   rebalancer,
+	}
+}
+
+// AddCheck adds a check that must pass for the bot to be ready
+func (c *Checker) AddCheck(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Register serves liveness on /healthz and readiness on /readyz
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.serveLiveness)
+	mux.HandleFunc("/readyz", c.serveReadiness)
+}
+
+// Liveness only checks that block headers are still arriving, so that a
+// restart is only triggered when the bot itself is stuck
+func (c *Checker) Liveness() Report {
+	report := c.report()
+	report.Checks["subscription"] = result(c.checkSubscription())
+	report.Status = status(report.Checks)
+
+	return report
+}
+
+// Readiness checks the subscription and every added check
+func (c *Checker) Readiness(ctx context.Context) Report {
+	report := c.report()
+	report.Checks["subscription"] = result(c.checkSubscription())
+
+	for _, nc := range c.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		report.Checks[nc.name] = result(nc.check(checkCtx))
+		cancel()
+	}
+
+	report.Status = status(report.Checks)
+
+	return report
+}
+
+func (c *Checker) serveLiveness(w http.ResponseWriter, _ *http.Request) {
+	c.write(w, c.Liveness())
+}
+
+func (c *Checker) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	c.write(w, c.Readiness(r.Context()))
+}
+
+func (c *Checker) write(w http.ResponseWriter, report Report) {
+	code := http.StatusOK
+	if report.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		c.l.Debug("Failed to write health report", zap.Error(err))
+	}
+}
+
+func (c *Checker) report() Report {
+	return Report{
+		LastEvent:     optionalTime(c.subscription.LastEvent()),
+		LastHeader:    optionalTime(c.subscription.LastHeader()),
+		LastRebalance: optionalTime(c.rebalancer.LastRebalance()),
+		Checks:        make(map[string]CheckResult),
+	}
+}
+
+// checkSubscription fails when no block header arrived within the stale
+// timeout, counting from startup until the first header
+func (c *Checker) checkSubscription() error {
+	last := c.subscription.LastHeader()
+	if last.IsZero() {
+		last = c.startedAt
+	}
+
+	if since := time.Since(last); since > c.staleTimeout {
+		return fmt.Errorf("no block header for %s", since.Round(time.Second))
+	}
+
+	return nil
+}
+
+// GRPCCheck checks that the gRPC endpoint in use serves the latest block
+func GRPCCheck(clients *endpoints.Clients) Check {
+	return func(ctx context.Context) error {
+		c, err := clients.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = endpoints.LatestHeight(ctx, c.GRPCClient)
+		return err
+	}
+}
+
+// BalanceCheck checks that the signer holds at least the minimum balance
+func BalanceCheck(clients *endpoints.Clients, address string, min sdk.Coin) Check {
+	return func(ctx context.Context) error {
+		c, err := clients.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		balances, err := queries.GetBalances(ctx, c.BankClient, address)
+		if err != nil {
+			return err
+		}
+
+		if balance := balances.AmountOf(min.Denom); balance.LT(min.Amount) {
+			return fmt.Errorf("balance %s%s below %s", balance, min.Denom, min)
+		}
+
+		return nil
+	}
+}
+
+// PausedCheck checks that the power contract is not paused
+func PausedCheck(clients *endpoints.Clients, contractAddress string) Check {
+	return func(ctx context.Context) error {
+		c, err := clients.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, state, err := power.GetConfigAndState(ctx, c.WasmClient, contractAddress)
+		if err != nil {
+			return err
+		}
+
+		if state.IsPaused {
+			return fmt.Errorf("power contract %s is paused", contractAddress)
+		}
+
+		return nil
+	}
+}
+
+func result(err error) CheckResult {
+	if err != nil {
+		return CheckResult{Error: err.Error()}
+	}
+	return CheckResult{OK: true}
+}
+
+func status(checks map[string]CheckResult) string {
+	for _, check := range checks {
+		if !check.OK {
+			return "unavailable"
+		}
+	}
+	return "ok"
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
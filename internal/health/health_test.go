@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gotest.tools/assert"
+)
+
+type fakeSubscription struct {
+	lastEvent  time.Time
+	lastHeader time.Time
+}
+
+func (s fakeSubscription) LastEvent() time.Time  { return s.lastEvent }
+func (s fakeSubscription) LastHeader() time.Time { return s.lastHeader }
+
+type fakeRebalancer struct {
+	lastRebalance time.Time
+}
+
+func (r fakeRebalancer) LastRebalance() time.Time { return r.lastRebalance }
+
+func get(t *testing.T, c *Checker, path string) (int, Report) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	c.Register(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + path)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+
+	var report Report
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&report))
+
+	return resp.StatusCode, report
+}
+
+func TestLiveness(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		lastHeader time.Time
+		startedAt  time.Time
+		want       int
+	}{
+		{"recent header", now.Add(-time.Second), now.Add(-time.Hour), http.StatusOK},
+		{"stale header", now.Add(-10 * time.Minute), now.Add(-time.Hour), http.StatusServiceUnavailable},
+		{"no header within startup grace", time.Time{}, now, http.StatusOK},
+		{"no header after startup grace", time.Time{}, now.Add(-time.Hour), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(zap.NewNop(), 5*time.Minute, fakeSubscription{lastHeader: tt.lastHeader}, fakeRebalancer{})
+			c.startedAt = tt.startedAt
+
+			code, report := get(t, c, "/healthz")
+			assert.Equal(t, code, tt.want)
+			assert.Equal(t, report.Checks["subscription"].OK, tt.want == http.StatusOK)
+		})
+	}
+}
+
+func TestLivenessIgnoresReadinessChecks(t *testing.T) {
+	c := NewChecker(zap.NewNop(), time.Minute, fakeSubscription{lastHeader: time.Now()}, fakeRebalancer{})
+	c.AddCheck("grpc", func(context.Context) error { return errors.New("unreachable") })
+
+	code, _ := get(t, c, "/healthz")
+	assert.Equal(t, code, http.StatusOK)
+}
+
+func TestReadiness(t *testing.T) {
+	rebalanced := time.Now().Add(-time.Minute)
+
+	c := NewChecker(zap.NewNop(), time.Minute, fakeSubscription{lastHeader: time.Now()}, fakeRebalancer{lastRebalance: rebalanced})
+	c.AddCheck("grpc", func(context.Context) error { return nil })
+	c.AddCheck("power_paused", func(context.Context) error { return errors.New("power contract is paused") })
+
+	code, report := get(t, c, "/readyz")
+	assert.Equal(t, code, http.StatusServiceUnavailable)
+	assert.Equal(t, report.Status, "unavailable")
+	assert.Equal(t, report.Checks["grpc"].OK, true)
+	assert.Equal(t, report.Checks["power_paused"].OK, false)
+	assert.Equal(t, report.Checks["power_paused"].Error, "power contract is paused")
+	assert.Assert(t, report.LastRebalance != nil)
+	assert.Assert(t, report.LastRebalance.Equal(rebalanced))
+	assert.Assert(t, report.LastEvent == nil)
+}
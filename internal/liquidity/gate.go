@@ -0,0 +1,57 @@
+package liquidity
+
+import "math"
+
+// PremiumGate decides whether the premium has moved far enough from the
+// theoretical price to justify repositioning liquidity.
+//
+// A rebalance is allowed once the absolute premium reaches the threshold.
+// After that, further rebalances require the premium to move by at least the
+// hysteresis from the last rebalance, and the gate only re-arms once the
+// absolute premium falls back below threshold - hysteresis. This stops the bot
+// from flapping when the premium oscillates around the threshold.
+type PremiumGate struct {
+	threshold  float64
+	hysteresis float64
+
+	armed bool
+	last  float64
+}
+
+// NewPremiumGate returns an armed gate for the given threshold and hysteresis.
+// A zero threshold and hysteresis allows every rebalance.
+func NewPremiumGate(threshold, hysteresis float64) *PremiumGate {
+	return &PremiumGate{
+		threshold:  threshold,
+		hysteresis: hysteresis,
+		armed:      true,
+	}
+}
+
+// ShouldRebalance reports whether positions should be moved for the given
+// premium. If the bot has no open positions it always returns true so that
+// the initial positions are created. Successful rebalances must be reported
+// with Record.
+func (g *PremiumGate) ShouldRebalance(premium float64, hasPositions bool) bool {
+	deviation := math.Abs(premium)
+
+	if !hasPositions {
+		return true
+	}
+
+	if deviation < g.threshold-g.hysteresis {
+		g.armed = true
+	}
+
+	if deviation < g.threshold {
+		return false
+	}
+
+	return g.armed || math.Abs(premium-g.last) >= g.hysteresis
+}
+
+// Record stores the premium of the latest rebalance and disarms the gate
+func (g *PremiumGate) Record(premium float64) {
+	g.armed = false
+	g.last = premium
+}
@@ -0,0 +1,69 @@
+package liquidity
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// rebalance reports whether the gate allows a rebalance and records it if so
+func rebalance(gate *PremiumGate, premium float64, hasPositions bool) bool {
+	if !gate.ShouldRebalance(premium, hasPositions) {
+		return false
+	}
+	gate.Record(premium)
+	return true
+}
+
+func TestPremiumGateZeroThresholdAlwaysRebalances(t *testing.T) {
+	gate := NewPremiumGate(0, 0)
+
+	assert.Equal(t, true, rebalance(gate, 0, true))
+	assert.Equal(t, true, rebalance(gate, 0.001, true))
+	assert.Equal(t, true, rebalance(gate, -0.001, true))
+}
+
+func TestPremiumGateNoPositionsAlwaysRebalances(t *testing.T) {
+	gate := NewPremiumGate(0.05, 0.01)
+
+	assert.Equal(t, true, rebalance(gate, 0, false))
+	assert.Equal(t, false, rebalance(gate, 0, true))
+}
+
+func TestPremiumGateInsideBand(t *testing.T) {
+	gate := NewPremiumGate(0.05, 0.01)
+
+	assert.Equal(t, false, rebalance(gate, 0.01, true))
+	assert.Equal(t, false, rebalance(gate, -0.049, true))
+	assert.Equal(t, true, rebalance(gate, -0.05, true))
+}
+
+func TestPremiumGateHysteresis(t *testing.T) {
+	gate := NewPremiumGate(0.05, 0.01)
+
+	// crossing the threshold triggers a rebalance
+	assert.Equal(t, true, rebalance(gate, 0.06, true))
+
+	// small moves above the threshold are ignored
+	assert.Equal(t, false, rebalance(gate, 0.065, true))
+	assert.Equal(t, false, rebalance(gate, 0.055, true))
+
+	// dipping just below the threshold does not re-arm the gate
+	assert.Equal(t, false, rebalance(gate, 0.045, true))
+	assert.Equal(t, false, rebalance(gate, 0.051, true))
+
+	// moving further out by the hysteresis triggers again
+	assert.Equal(t, true, rebalance(gate, 0.07, true))
+
+	// falling below threshold - hysteresis re-arms the gate
+	assert.Equal(t, false, rebalance(gate, 0.03, true))
+	assert.Equal(t, true, rebalance(gate, 0.068, true))
+}
+
+func TestPremiumGateUnrecordedRebalanceStaysOpen(t *testing.T) {
+	gate := NewPremiumGate(0.05, 0.01)
+
+	// a failed rebalance is not recorded, so the retry is still allowed
+	assert.Equal(t, true, gate.ShouldRebalance(0.06, true))
+	assert.Equal(t, true, gate.ShouldRebalance(0.06, true))
+}
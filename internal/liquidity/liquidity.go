@@ -11,22 +11,24 @@ import (
 	model "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/model"
 	cltypes "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/types"
 	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/types"
 )
 
-const TICK_SPACING = int64(100)
+// DefaultSlippageTolerance is used when no slippage tolerance is configured
+const DefaultSlippageTolerance = "0.01"
 
-// createPositionMsg creates a new CL position message
-func createPositionMsg(poolId uint64, lowerTick, upperTick int64, tokens sdk.Coins, addr string, isBuy bool) sdk.Msg {
-	var amount0, amount1 sdkmath.Int
-	if isBuy {
-		amount0, amount1 = sdk.ZeroInt(), sdk.ZeroInt()
-	} else {
-		amount0, amount1 = sdk.ZeroInt(), sdk.ZeroInt()
+// createPositionMsg creates a new CL position message with minimum amounts
+// derived from the slippage tolerance
+func createPositionMsg(pool types.ConcentratedPool, lowerTick, upperTick int64, tokens sdk.Coins, addr string, slippage osmomath.Dec) (sdk.Msg, error) {
+	amount0, amount1, err := minAmounts(pool, lowerTick, upperTick, tokens.AmountOf(pool.Token0), tokens.AmountOf(pool.Token1), slippage)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate the swap message
+	// Generate the create position message
 	msg := cltypes.MsgCreatePosition{
-		PoolId:          poolId,
+		PoolId:          pool.ID,
 		Sender:          addr,
 		LowerTick:       lowerTick,
 		UpperTick:       upperTick,
@@ -35,7 +37,43 @@ func createPositionMsg(poolId uint64, lowerTick, upperTick int64, tokens sdk.Coi
 		TokenMinAmount1: amount1,
 	}
 
-	return &msg
+	return &msg, nil
+}
+
+// minAmounts returns the minimum amounts of token0 and token1 that must be
+// deposited when providing amount0 and amount1 to the range. The expected
+// amounts follow from the liquidity the tokens provide at the current sqrt
+// price, and are reduced by the slippage tolerance.
+func minAmounts(pool types.ConcentratedPool, lowerTick, upperTick int64, amount0, amount1 sdkmath.Int, slippage osmomath.Dec) (sdkmath.Int, sdkmath.Int, error) {
+	sqrtPriceLower, sqrtPriceUpper, err := clmath.TicksToSqrtPrice(lowerTick, upperTick)
+	if err != nil {
+		return sdkmath.Int{}, sdkmath.Int{}, err
+	}
+
+	liquidity := clmath.GetLiquidityFromAmounts(pool.CurrentSqrtPrice, sqrtPriceLower, sqrtPriceUpper, amount0, amount1)
+	if !liquidity.IsPositive() {
+		return sdk.ZeroInt(), sdk.ZeroInt(), nil
+	}
+
+	liquidityBigDec := osmomath.BigDecFromDec(liquidity)
+	expected0, expected1 := osmomath.ZeroBigDec(), osmomath.ZeroBigDec()
+
+	switch {
+	case lowerTick <= pool.CurrentTick && pool.CurrentTick < upperTick:
+		expected0 = clmath.CalcAmount0Delta(liquidityBigDec, pool.CurrentSqrtPrice, sqrtPriceUpper, false)
+		expected1 = clmath.CalcAmount1Delta(liquidityBigDec, pool.CurrentSqrtPrice, sqrtPriceLower, false)
+	case pool.CurrentTick < lowerTick:
+		expected0 = clmath.CalcAmount0Delta(liquidityBigDec, sqrtPriceLower, sqrtPriceUpper, false)
+	default:
+		expected1 = clmath.CalcAmount1Delta(liquidityBigDec, sqrtPriceLower, sqrtPriceUpper, false)
+	}
+
+	factor := osmomath.BigDecFromDec(osmomath.OneDec().Sub(slippage))
+
+	min0 := sdk.MinInt(expected0.Mul(factor).Dec().TruncateInt(), amount0)
+	min1 := sdk.MinInt(expected1.Mul(factor).Dec().TruncateInt(), amount1)
+
+	return min0, min1, nil
 }
 
 // removePositionMsg withdraws positions with specific ids
@@ -68,8 +106,9 @@ func RemovePreviousPositions(l *zap.Logger, positions []model.FullPositionBreakd
 	return msgs
 }
 
-// marketMake creates a market making positions
-func MarketMake(l *zap.Logger, poolId uint64, currentTick int64, spotPrice, targetPrice, spread string, token0 sdk.Coin, token1 sdk.Coin, addr string) ([]sdk.Msg, error) {
+// MarketMake calculates a buy position below and a sell position above the
+// spot and target prices
+func MarketMake(l *zap.Logger, currentTick, tickSpacing int64, spotPrice, targetPrice, spread string, token0 sdk.Coin, token1 sdk.Coin) ([]DesiredPosition, error) {
 	l.Debug("inputs",
 		zap.String("spotPrice", spotPrice),
 		zap.String("targetPrice", targetPrice),
@@ -97,14 +136,14 @@ func MarketMake(l *zap.Logger, poolId uint64, currentTick int64, spotPrice, targ
 		targetPriceAsBigDec, spotPriceAsBigDec = spotPriceAsBigDec, targetPriceAsBigDec
 	}
 
-	buyTick, lowTick, sellTick, highTick, err := calculateBuySellTicks(l, targetPriceAsBigDec, spotPriceAsBigDec, spreadAsBigDec)
+	buyTick, lowTick, sellTick, highTick, err := calculateBuySellTicks(l, targetPriceAsBigDec, spotPriceAsBigDec, spreadAsBigDec, tickSpacing)
 	if err != nil {
 		l.Error("Failed to calculate buy and sell ticks", zap.Error(err))
 		return nil, err
 	}
 
-	lowTick, buyTick = adjustForCurrentTick(l, true, currentTick, lowTick, buyTick)
-	sellTick, highTick = adjustForCurrentTick(l, false, currentTick, sellTick, highTick)
+	lowTick, buyTick = adjustForCurrentTick(l, true, currentTick, lowTick, buyTick, tickSpacing)
+	sellTick, highTick = adjustForCurrentTick(l, false, currentTick, sellTick, highTick, tickSpacing)
 
 	if !(lowTick < buyTick && buyTick < sellTick && sellTick < highTick) {
 		err := errors.New("ticks are in the incorrect order")
@@ -112,37 +151,89 @@ func MarketMake(l *zap.Logger, poolId uint64, currentTick int64, spotPrice, targ
 		return nil, err
 	}
 
-	buyPosition := createPositionMsg(poolId, lowTick, buyTick, sdk.NewCoins(token1), addr, true)
-	sellPosition := createPositionMsg(poolId, sellTick, highTick, sdk.NewCoins(token0), addr, false)
+	buyPosition := DesiredPosition{LowerTick: lowTick, UpperTick: buyTick, Tokens: sdk.NewCoins(token1)}
+	sellPosition := DesiredPosition{LowerTick: sellTick, UpperTick: highTick, Tokens: sdk.NewCoins(token0)}
+
+	l.Debug("desired positions",
+		zap.Reflect("buyPosition", buyPosition),
+		zap.Reflect("sellPosition", sellPosition),
+	)
 
-	fmt.Println("buyPosition", buyPosition)
-	fmt.Println("sellPosition", sellPosition)
+	return []DesiredPosition{buyPosition, sellPosition}, nil
+}
 
-	return []sdk.Msg{buyPosition, sellPosition}, nil
+// ValidatePoolAssets checks that the configured base and quote assets are the
+// token0 and token1 of the pool
+func ValidatePoolAssets(pool types.ConcentratedPool, baseAsset, quoteAsset string) error {
+	if pool.Token0 != baseAsset || pool.Token1 != quoteAsset {
+		return fmt.Errorf("pool %d has token0 %q and token1 %q but base_asset is %q and quote_asset is %q",
+			pool.ID, pool.Token0, pool.Token1, baseAsset, quoteAsset)
+	}
+
+	return nil
 }
 
-func adjustForCurrentTick(l *zap.Logger, isBuy bool, currentTick, lowerTick, upperTick int64) (int64, int64) {
+// SplitPosition splits a position into a ladder of n adjacent ranges of equal
+// width, dividing its tokens evenly between them. The number of ranges is
+// reduced if the position is too narrow for n ranges of at least one tick
+// spacing.
+func SplitPosition(p DesiredPosition, n int, tickSpacing int64) []DesiredPosition {
+	if maxRanges := (p.UpperTick - p.LowerTick) / tickSpacing; int64(n) > maxRanges {
+		n = int(maxRanges)
+	}
+
+	if n <= 1 {
+		return []DesiredPosition{p}
+	}
+
+	width := (p.UpperTick - p.LowerTick) / int64(n) / tickSpacing * tickSpacing
+
+	ladder := make([]DesiredPosition, n)
+	remaining := p.Tokens
 
-	fmt.Println("lowerTick", lowerTick)
+	for i := 0; i < n; i++ {
+		lowerTick := p.LowerTick + int64(i)*width
+		upperTick := lowerTick + width
 
+		tokens := remaining
+		if i == n-1 {
+			upperTick = p.UpperTick
+		} else {
+			tokens = sdk.Coins{}
+			for _, c := range p.Tokens {
+				tokens = tokens.Add(sdk.NewCoin(c.Denom, c.Amount.QuoRaw(int64(n))))
+			}
+			remaining = remaining.Sub(tokens...)
+		}
+
+		ladder[i] = DesiredPosition{LowerTick: lowerTick, UpperTick: upperTick, Tokens: tokens}
+	}
+
+	return ladder
+}
+
+func adjustForCurrentTick(l *zap.Logger, isBuy bool, currentTick, lowerTick, upperTick, tickSpacing int64) (int64, int64) {
 	if lowerTick <= currentTick && currentTick <= upperTick {
-		fmt.Println("The value is within the range.")
+		l.Debug("Current tick is within the range",
+			zap.Bool("isBuy", isBuy),
+			zap.Int64("currentTick", currentTick),
+			zap.Int64("lowerTick", lowerTick),
+			zap.Int64("upperTick", upperTick),
+		)
 
 		if isBuy {
-			upperTick = currentTick - TICK_SPACING
+			upperTick = currentTick - tickSpacing
 		} else {
-			lowerTick = currentTick + TICK_SPACING
+			lowerTick = currentTick + tickSpacing
 		}
 	}
 
-	fmt.Println("lowerTick", lowerTick)
-
-	upperTick, err := clmath.RoundDownTickToSpacing(upperTick, TICK_SPACING)
+	upperTick, err := clmath.RoundDownTickToSpacing(upperTick, tickSpacing)
 	if err != nil {
 		l.Error("Failed to calculate buy price tick", zap.Error(err))
 	}
 
-	lowerTick, err = clmath.RoundDownTickToSpacing(lowerTick, TICK_SPACING)
+	lowerTick, err = clmath.RoundDownTickToSpacing(lowerTick, tickSpacing)
 	if err != nil {
 		l.Error("Failed to calculate buy price tick", zap.Error(err))
 	}
@@ -153,36 +244,36 @@ func adjustForCurrentTick(l *zap.Logger, isBuy bool, currentTick, lowerTick, upp
 		lowerDelta = -lowerDelta
 	}
 
-	// Check if lowerDelta is less than TICK_SPACING and adjust lowerTick if necessary
-	if lowerDelta < TICK_SPACING {
-		lowerTick += (3 * TICK_SPACING)
+	// Check if lowerDelta is less than the tick spacing and adjust lowerTick if necessary
+	if lowerDelta < tickSpacing {
+		lowerTick += (3 * tickSpacing)
 	}
 
 	return lowerTick, upperTick
 }
 
-func calculateBuySellTicks(l *zap.Logger, buyPrice, sellPrice, spread osmomath.BigDec) (int64, int64, int64, int64, error) {
+func calculateBuySellTicks(l *zap.Logger, buyPrice, sellPrice, spread osmomath.BigDec, tickSpacing int64) (int64, int64, int64, int64, error) {
 	// get the lower and upper bounds
 	buyLowerBound := buyPrice.Mul(osmomath.OneBigDec().Sub(spread))
 	sellUpperBound := sellPrice.Mul(osmomath.OneBigDec().Add(spread))
 
 	// Calculate the buy and sell ticks
-	buyPriceTick, err := calculateAndRoundPriceToTick(buyPrice)
+	buyPriceTick, err := calculateAndRoundPriceToTick(buyPrice, tickSpacing)
 	if err != nil {
 		l.Error("Failed to calculate buy price tick", zap.Error(err))
 	}
 
-	buyLowerTick, err := calculateAndRoundPriceToTick(buyLowerBound)
+	buyLowerTick, err := calculateAndRoundPriceToTick(buyLowerBound, tickSpacing)
 	if err != nil {
 		l.Error("Failed to calculate buy lower bound price tick", zap.Error(err))
 	}
 
-	sellPriceTick, err := calculateAndRoundPriceToTick(sellPrice)
+	sellPriceTick, err := calculateAndRoundPriceToTick(sellPrice, tickSpacing)
 	if err != nil {
 		l.Error("Failed to calculate sell price tick", zap.Error(err))
 	}
 
-	sellUpperTick, err := calculateAndRoundPriceToTick(sellUpperBound)
+	sellUpperTick, err := calculateAndRoundPriceToTick(sellUpperBound, tickSpacing)
 	if err != nil {
 		l.Error("Failed to calculate sell upper bound price tick", zap.Error(err))
 	}
@@ -191,13 +282,13 @@ func calculateBuySellTicks(l *zap.Logger, buyPrice, sellPrice, spread osmomath.B
 
 }
 
-func calculateAndRoundPriceToTick(price osmomath.BigDec) (int64, error) {
+func calculateAndRoundPriceToTick(price osmomath.BigDec, tickSpacing int64) (int64, error) {
 	priceTick, err := clmath.CalculatePriceToTick(price)
 	if err != nil {
 		return 0, err
 	}
 
-	priceTick, err = clmath.RoundDownTickToSpacing(priceTick, TICK_SPACING)
+	priceTick, err = clmath.RoundDownTickToSpacing(priceTick, tickSpacing)
 	if err != nil {
 		return 0, err
 	}
@@ -3,6 +3,7 @@ package liquidity
 import (
 	"testing"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/osmosis-labs/osmosis/osmomath"
 	"go.uber.org/zap"
 	"gotest.tools/assert"
@@ -16,7 +17,7 @@ func TestCalculateBuySellTicksBasicFunctionality(t *testing.T) {
 	sellPrice, _ := osmomath.NewBigDecFromStr("1.0")
 	spread, _ := osmomath.NewBigDecFromStr("0.1") // 10% spread
 
-	buyPriceTick, buyLowerTick, sellPriceTick, sellUpperTick, _ := calculateBuySellTicks(logger, buyPrice, sellPrice, spread)
+	buyPriceTick, buyLowerTick, sellPriceTick, sellUpperTick, _ := calculateBuySellTicks(logger, buyPrice, sellPrice, spread, 100)
 
 	// Assertions
 	assert.Equal(t, int64(0), buyPriceTick, "Buy price tick should match expected value")
@@ -33,7 +34,7 @@ func TestCalculateBuySellTicksMidPriceLessThanOne(t *testing.T) {
 	sellPrice, _ := osmomath.NewBigDecFromStr("1.0")
 	spread, _ := osmomath.NewBigDecFromStr("0.1") // 10% spread
 
-	buyPriceTick, buyLowerTick, sellPriceTick, sellUpperTick, _ := calculateBuySellTicks(logger, buyPrice, sellPrice, spread)
+	buyPriceTick, buyLowerTick, sellPriceTick, sellUpperTick, _ := calculateBuySellTicks(logger, buyPrice, sellPrice, spread, 100)
 
 	// Assertions
 	assert.Equal(t, int64(-1000000), buyPriceTick, "Buy price tick should match expected value")
@@ -50,7 +51,7 @@ func TestCalculateBuySellTicksMidPriceGreaterThanOne(t *testing.T) {
 	sellPrice, _ := osmomath.NewBigDecFromStr("10.3")
 	spread, _ := osmomath.NewBigDecFromStr("0.1") // 10% spread
 
-	buyPriceTick, buyLowerTick, sellPriceTick, sellUpperTick, _ := calculateBuySellTicks(logger, buyPrice, sellPrice, spread)
+	buyPriceTick, buyLowerTick, sellPriceTick, sellUpperTick, _ := calculateBuySellTicks(logger, buyPrice, sellPrice, spread, 100)
 
 	// Assertions
 	assert.Equal(t, int64(9010000), buyPriceTick, "Buy price tick should match expected value")
@@ -67,7 +68,7 @@ func TestCalculateBuySellTicksValueUnderZero(t *testing.T) {
 	sellPrice, _ := osmomath.NewBigDecFromStr("0.18")
 	spread, _ := osmomath.NewBigDecFromStr("0.1") // 10% spread
 
-	buyPriceTick, buyLowerTick, sellPriceTick, sellUpperTick, _ := calculateBuySellTicks(logger, buyPrice, sellPrice, spread)
+	buyPriceTick, buyLowerTick, sellPriceTick, sellUpperTick, _ := calculateBuySellTicks(logger, buyPrice, sellPrice, spread, 100)
 
 	// Assertions
 	assert.Equal(t, int64(-8300000), buyPriceTick, "Buy price tick should match expected value")
@@ -75,3 +76,35 @@ func TestCalculateBuySellTicksValueUnderZero(t *testing.T) {
 	assert.Equal(t, int64(-8200000), sellPriceTick, "Sell price tick should match expected value")
 	assert.Equal(t, int64(-8020000), sellUpperTick, "Sell upper tick should match expected value")
 }
+
+func TestSplitPosition(t *testing.T) {
+	p := DesiredPosition{
+		LowerTick: -1000,
+		UpperTick: 0,
+		Tokens:    sdk.NewCoins(sdk.NewInt64Coin("quote", 100)),
+	}
+
+	ladder := SplitPosition(p, 3, 100)
+
+	assert.Equal(t, 3, len(ladder))
+	assert.Equal(t, int64(-1000), ladder[0].LowerTick)
+	assert.Equal(t, int64(-700), ladder[0].UpperTick)
+	assert.Equal(t, int64(-700), ladder[1].LowerTick)
+	assert.Equal(t, int64(-400), ladder[1].UpperTick)
+	assert.Equal(t, int64(-400), ladder[2].LowerTick)
+	assert.Equal(t, int64(0), ladder[2].UpperTick)
+	assert.Equal(t, "33quote", ladder[0].Tokens.String())
+	assert.Equal(t, "33quote", ladder[1].Tokens.String())
+	assert.Equal(t, "34quote", ladder[2].Tokens.String())
+}
+
+func TestSplitPositionTooNarrow(t *testing.T) {
+	p := DesiredPosition{
+		LowerTick: -200,
+		UpperTick: 0,
+		Tokens:    sdk.NewCoins(sdk.NewInt64Coin("quote", 100)),
+	}
+
+	assert.Equal(t, 2, len(SplitPosition(p, 5, 100)))
+	assert.Equal(t, 1, len(SplitPosition(p, 0, 100)))
+}
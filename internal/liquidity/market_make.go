@@ -0,0 +1,94 @@
+package liquidity
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+func init() {
+	RegisterStrategy(DefaultStrategy, newMarketMakeStrategy)
+}
+
+// marketMakeStrategy places a buy range below and a sell range above the
+// spot and target prices, see MarketMake. Each range can be split into a
+// ladder of smaller ranges.
+type marketMakeStrategy struct {
+	spread              string
+	ranges              int
+	useWalletBalance    bool
+	defaultToken0Amount int64
+	defaultToken1Amount int64
+}
+
+func newMarketMakeStrategy(cfg *types.Config) (Strategy, error) {
+	return &marketMakeStrategy{
+		spread:              cfg.Position.Spread,
+		ranges:              cfg.Position.Ranges,
+		useWalletBalance:    cfg.Position.UseWalletBalance,
+		defaultToken0Amount: cfg.Position.DefaultToken0Amount,
+		defaultToken1Amount: cfg.Position.DefaultToken1Amount,
+	}, nil
+}
+
+func (s *marketMakeStrategy) Name() string {
+	return DefaultStrategy
+}
+
+func (s *marketMakeStrategy) DesiredPositions(l *zap.Logger, snapshot MarketSnapshot) ([]DesiredPosition, error) {
+	token0, token1 := s.deployableTokens(l, snapshot)
+
+	// Both ranges need funds, and a position created without any is
+	// rejected on chain
+	if !token0.IsPositive() || !token1.IsPositive() {
+		return nil, fmt.Errorf("%w: %s and %s available", ErrNothingToDeploy, token0, token1)
+	}
+
+	positions, err := MarketMake(l, snapshot.CurrentTick, snapshot.Pool.TickSpacing, snapshot.SpotPrice, snapshot.TargetPrice, s.spread, token0, token1)
+	if err != nil {
+		return nil, err
+	}
+
+	var ladder []DesiredPosition
+	for _, p := range positions {
+		ladder = append(ladder, SplitPosition(p, s.ranges, snapshot.Pool.TickSpacing)...)
+	}
+
+	return ladder, nil
+}
+
+// deployableTokens sums the assets of every open position, plus the free
+// wallet balance if enabled. Without open positions or wallet funds the
+// default amounts are used.
+func (s *marketMakeStrategy) deployableTokens(l *zap.Logger, snapshot MarketSnapshot) (sdk.Coin, sdk.Coin) {
+	token0 := sdk.NewCoin(snapshot.Pool.Token0, sdk.ZeroInt())
+	token1 := sdk.NewCoin(snapshot.Pool.Token1, sdk.ZeroInt())
+
+	for _, p := range snapshot.Positions {
+		token0 = token0.AddAmount(p.Asset0.Amount)
+		token1 = token1.AddAmount(p.Asset1.Amount)
+	}
+
+	if s.useWalletBalance {
+		token0 = token0.AddAmount(snapshot.Balances.AmountOf(snapshot.Pool.Token0))
+		token1 = token1.AddAmount(snapshot.Balances.AmountOf(snapshot.Pool.Token1))
+	}
+
+	if len(snapshot.Positions) == 0 && !s.useWalletBalance {
+		l.Info("No positions found")
+
+		token0 = sdk.NewCoin(snapshot.Pool.Token0, sdk.NewInt(s.defaultToken0Amount))
+		token1 = sdk.NewCoin(snapshot.Pool.Token1, sdk.NewInt(s.defaultToken1Amount))
+	}
+
+	l.Debug("tokens",
+		zap.Int("positions", len(snapshot.Positions)),
+		zap.String("token0", token0.String()),
+		zap.String("token1", token1.String()),
+	)
+
+	return token0, token1
+}
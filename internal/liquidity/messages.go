@@ -1,79 +1,60 @@
 package liquidity
 
 import (
+	"fmt"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
-	clquery "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/client/queryproto"
+	"github.com/osmosis-labs/osmosis/osmomath"
 	"go.uber.org/zap"
-
-	"github.com/margined-protocol/flood/internal/types"
 )
 
-func CreateUpdatePositionMsgs(l *zap.Logger, p clquery.UserPositionsResponse, cfg *types.Config, currentTick int64, address, powerPrice, targetPrice string) ([]sdk.Msg, error) {
-	var msgs []sdk.Msg
-
-	var token0 sdk.Coin
-	var token1 sdk.Coin
-
-	if p.Positions == nil {
-		l.Info("No positions found")
-
-		token0.Amount = sdk.NewInt(cfg.Position.DefaultToken0Amount)
-		token0.Denom = cfg.PowerPool.BaseAsset
-
-		token1.Amount = sdk.NewInt(cfg.Position.DefaultToken1Amount)
-		token1.Denom = cfg.PowerPool.QuoteAsset
+// CreateUpdatePositionMsgs asks the strategy for the positions it wants and
+// reconciles them against the existing positions. It also returns the wallet
+// funds the messages may spend.
+func CreateUpdatePositionMsgs(l *zap.Logger, strategy Strategy, snapshot MarketSnapshot, address string, slippage osmomath.Dec) ([]sdk.Msg, sdk.Coins, error) {
+	l.Debug("existing positions",
+		zap.Reflect("Positions", snapshot.Positions),
+	)
 
+	desired, err := strategy.DesiredPositions(l, snapshot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("strategy %s: %w", strategy.Name(), err)
 	}
 
-	if len(p.Positions) == 1 {
-		l.Info("Found open positions")
-
-		l.Debug("existing positions",
-			zap.Reflect("Positions", p.Positions),
-		)
-
-		removeMsgs := RemovePreviousPositions(l, p.Positions)
-		msgs = append(msgs, removeMsgs...)
-
-		l.Debug("removing positions",
-			zap.Reflect("removeMsgs", removeMsgs),
-		)
-
-		return msgs, nil
+	// Wallet funds are only used for the part of the desired positions that
+	// is not already covered by the assets held in the pool
+	var desiredTotal, existingTotal sdk.Coins
+	for _, p := range desired {
+		desiredTotal = desiredTotal.Add(p.Tokens...)
 	}
+	for _, p := range snapshot.Positions {
+		existingTotal = existingTotal.Add(positionAssets(p)...)
+	}
+	free := clampCoins(missingCoins(desiredTotal, existingTotal), snapshot.Balances)
 
-	if len(p.Positions) == 2 {
-		l.Info("Found open positions")
-
-		l.Debug("existing positions",
-			zap.Reflect("Positions", p.Positions),
-		)
-
-		removeMsgs := RemovePreviousPositions(l, p.Positions)
-		msgs = append(msgs, removeMsgs...)
-
-		l.Debug("removing positions",
-			zap.Reflect("removeMsgs", removeMsgs),
-		)
-
-		amount0 := p.Positions[0].Asset0.AddAmount(p.Positions[1].Asset0.Amount)
-		amount1 := p.Positions[0].Asset1.AddAmount(p.Positions[1].Asset1.Amount)
+	msgs, err := Reconcile(l, snapshot.Pool, address, snapshot.Positions, desired, free, slippage)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		token0 = sdk.NewCoin(p.Positions[0].Asset0.Denom, amount0.Amount)
-		token1 = sdk.NewCoin(p.Positions[0].Asset1.Denom, amount1.Amount)
+	return msgs, free, nil
+}
 
-		l.Debug("tokens",
-			zap.Int64("token0", token0.Amount.Int64()),
-			zap.Int64("token1", token1.Amount.Int64()),
-		)
+// ParseSlippageTolerance parses the configured slippage tolerance, falling
+// back to DefaultSlippageTolerance if it is empty
+func ParseSlippageTolerance(tolerance string) (osmomath.Dec, error) {
+	if tolerance == "" {
+		tolerance = DefaultSlippageTolerance
 	}
 
-	positionMsgs, err := MarketMake(l, cfg.PowerPool.PoolId, currentTick, powerPrice, targetPrice, cfg.Position.Spread, token0, token1, address)
+	slippage, err := osmomath.NewDecFromStr(tolerance)
 	if err != nil {
-		l.Fatal("Failed to market make", zap.Error(err))
-		return nil, err
+		return osmomath.Dec{}, err
 	}
 
-	return append(msgs, positionMsgs...), nil
+	if slippage.IsNegative() || slippage.GTE(osmomath.OneDec()) {
+		return osmomath.Dec{}, fmt.Errorf("slippage tolerance must be in [0, 1), got %s", tolerance)
+	}
 
+	return slippage, nil
 }
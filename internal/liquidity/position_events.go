@@ -0,0 +1,90 @@
+package liquidity
+
+import (
+	"fmt"
+	"strconv"
+
+	sdkmath "cosmossdk.io/math"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/osmosis-labs/osmosis/osmomath"
+	cltypes "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/types"
+)
+
+// PositionEvent is a change to a position emitted by an executed transaction.
+// Liquidity is the change in liquidity, which is negative for withdrawals.
+type PositionEvent struct {
+	Type       string
+	PoolID     uint64
+	PositionID uint64
+	LowerTick  int64
+	UpperTick  int64
+	Liquidity  osmomath.Dec
+	Amount0    sdkmath.Int
+	Amount1    sdkmath.Int
+}
+
+// ParsePositionEvents returns the create_position and withdraw_position
+// events of a transaction in the order they were emitted. Adding to a
+// position emits both, as the old position is withdrawn and a new one
+// created.
+func ParsePositionEvents(events []abci.Event) ([]PositionEvent, error) {
+	var positionEvents []PositionEvent
+
+	for _, event := range events {
+		if event.Type != cltypes.TypeEvtCreatePosition && event.Type != cltypes.TypeEvtWithdrawPosition {
+			continue
+		}
+
+		attributes := make(map[string]string, len(event.Attributes))
+		for _, attribute := range event.Attributes {
+			attributes[attribute.Key] = attribute.Value
+		}
+
+		positionEvent, err := parsePositionEvent(event.Type, attributes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s event: %w", event.Type, err)
+		}
+
+		positionEvents = append(positionEvents, positionEvent)
+	}
+
+	return positionEvents, nil
+}
+
+func parsePositionEvent(eventType string, attributes map[string]string) (PositionEvent, error) {
+	var (
+		e   = PositionEvent{Type: eventType}
+		ok  bool
+		err error
+	)
+
+	if e.PoolID, err = strconv.ParseUint(attributes[cltypes.AttributeKeyPoolId], 10, 64); err != nil {
+		return e, err
+	}
+
+	if e.PositionID, err = strconv.ParseUint(attributes[cltypes.AttributeKeyPositionId], 10, 64); err != nil {
+		return e, err
+	}
+
+	if e.LowerTick, err = strconv.ParseInt(attributes[cltypes.AttributeLowerTick], 10, 64); err != nil {
+		return e, err
+	}
+
+	if e.UpperTick, err = strconv.ParseInt(attributes[cltypes.AttributeUpperTick], 10, 64); err != nil {
+		return e, err
+	}
+
+	if e.Liquidity, err = osmomath.NewDecFromStr(attributes[cltypes.AttributeLiquidity]); err != nil {
+		return e, err
+	}
+
+	if e.Amount0, ok = sdkmath.NewIntFromString(attributes[cltypes.AttributeAmount0]); !ok {
+		return e, fmt.Errorf("invalid %s %q", cltypes.AttributeAmount0, attributes[cltypes.AttributeAmount0])
+	}
+
+	if e.Amount1, ok = sdkmath.NewIntFromString(attributes[cltypes.AttributeAmount1]); !ok {
+		return e, fmt.Errorf("invalid %s %q", cltypes.AttributeAmount1, attributes[cltypes.AttributeAmount1])
+	}
+
+	return e, nil
+}
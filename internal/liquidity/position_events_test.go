@@ -0,0 +1,71 @@
+package liquidity
+
+import (
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"gotest.tools/assert"
+)
+
+func testEvent(eventType string, attributes ...string) abci.Event {
+	event := abci.Event{Type: eventType}
+	for i := 0; i < len(attributes); i += 2 {
+		event.Attributes = append(event.Attributes, abci.EventAttribute{Key: attributes[i], Value: attributes[i+1]})
+	}
+	return event
+}
+
+func TestParsePositionEvents(t *testing.T) {
+	events := []abci.Event{
+		testEvent("message", "action", "/osmosis.concentratedliquidity.v1beta1.MsgWithdrawPosition"),
+		testEvent("withdraw_position",
+			"module", "concentratedliquidity",
+			"position_id", "1",
+			"sender", "osmo1bot",
+			"pool_id", "1299",
+			"lower_tick", "-200",
+			"upper_tick", "-100",
+			"liquidity", "-5.000000000000000000",
+			"amount0", "0",
+			"amount1", "-100",
+		),
+		testEvent("create_position",
+			"module", "concentratedliquidity",
+			"position_id", "2",
+			"sender", "osmo1bot",
+			"pool_id", "1299",
+			"lower_tick", "100",
+			"upper_tick", "200",
+			"liquidity", "7.000000000000000000",
+			"amount0", "100",
+			"amount1", "0",
+		),
+	}
+
+	positionEvents, err := ParsePositionEvents(events)
+	assert.NilError(t, err)
+	assert.Equal(t, len(positionEvents), 2)
+
+	withdraw := positionEvents[0]
+	assert.Equal(t, withdraw.Type, "withdraw_position")
+	assert.Equal(t, withdraw.PoolID, uint64(1299))
+	assert.Equal(t, withdraw.PositionID, uint64(1))
+	assert.Equal(t, withdraw.LowerTick, int64(-200))
+	assert.Equal(t, withdraw.UpperTick, int64(-100))
+	assert.Assert(t, withdraw.Liquidity.Equal(sdk.NewDec(-5)))
+	assert.Assert(t, withdraw.Amount1.Equal(sdk.NewInt(-100)))
+
+	create := positionEvents[1]
+	assert.Equal(t, create.Type, "create_position")
+	assert.Equal(t, create.PositionID, uint64(2))
+	assert.Assert(t, create.Liquidity.Equal(sdk.NewDec(7)))
+	assert.Assert(t, create.Amount0.Equal(sdk.NewInt(100)))
+}
+
+func TestParsePositionEventsInvalid(t *testing.T) {
+	_, err := ParsePositionEvents([]abci.Event{
+		testEvent("create_position", "position_id", "two"),
+	})
+	assert.ErrorContains(t, err, "parsing create_position event")
+}
@@ -0,0 +1,172 @@
+package liquidity
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/osmosis-labs/osmosis/osmomath"
+	model "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/model"
+	cltypes "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/types"
+	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+// Reconcile diffs the desired positions against the open positions and
+// returns the messages needed to move from one to the other.
+//
+// Open positions whose ticks match a desired position are kept so that their
+// spread reward accrual is not reset, and are topped up with MsgAddToPosition
+// if the strategy wants more tokens in them. All other open positions are
+// withdrawn and the remaining desired positions are created.
+//
+// New funds are limited to the assets released by the withdrawals plus free,
+// the wallet balance the caller allows the strategy to deploy. Every deposit
+// carries minimum amounts based on the slippage tolerance.
+func Reconcile(l *zap.Logger, pool types.ConcentratedPool, address string, existing []model.FullPositionBreakdown, desired []DesiredPosition, free sdk.Coins, slippage osmomath.Dec) ([]sdk.Msg, error) {
+	kept := make(map[int]int)
+	used := make(map[int]bool)
+
+	for i, d := range desired {
+		for j, p := range existing {
+			if used[j] {
+				continue
+			}
+			if p.Position.LowerTick == d.LowerTick && p.Position.UpperTick == d.UpperTick {
+				kept[i] = j
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	available := free
+
+	var withdrawMsgs, addMsgs, createMsgs []sdk.Msg
+
+	for j, p := range existing {
+		if used[j] {
+			l.Debug("keeping position",
+				zap.Uint64("positionId", p.Position.PositionId),
+				zap.Int64("lowerTick", p.Position.LowerTick),
+				zap.Int64("upperTick", p.Position.UpperTick),
+			)
+			continue
+		}
+
+		l.Debug("withdrawing position",
+			zap.Uint64("positionId", p.Position.PositionId),
+			zap.String("liquidity", p.Position.Liquidity.String()),
+		)
+
+		withdrawMsgs = append(withdrawMsgs, removePositionMsg(p.Position))
+		available = available.Add(positionAssets(p)...)
+	}
+
+	for i, d := range desired {
+		j, ok := kept[i]
+		if !ok {
+			continue
+		}
+
+		p := existing[j]
+		topUp := clampCoins(missingCoins(d.Tokens, positionAssets(p)), available)
+		if topUp.IsZero() {
+			continue
+		}
+		available = available.Sub(topUp...)
+
+		l.Debug("topping up position",
+			zap.Uint64("positionId", p.Position.PositionId),
+			zap.String("amount", topUp.String()),
+		)
+
+		msg, err := addToPositionMsg(pool, p, topUp, address, slippage)
+		if err != nil {
+			return nil, err
+		}
+
+		addMsgs = append(addMsgs, msg)
+	}
+
+	for i, d := range desired {
+		if _, ok := kept[i]; ok {
+			continue
+		}
+
+		tokens := clampCoins(d.Tokens, available)
+		if tokens.IsZero() {
+			l.Warn("Skipping position without available funds",
+				zap.Int64("lowerTick", d.LowerTick),
+				zap.Int64("upperTick", d.UpperTick),
+			)
+			continue
+		}
+		available = available.Sub(tokens...)
+
+		msg, err := createPositionMsg(pool, d.LowerTick, d.UpperTick, tokens, address, slippage)
+		if err != nil {
+			return nil, err
+		}
+
+		createMsgs = append(createMsgs, msg)
+	}
+
+	l.Info("Reconciled positions",
+		zap.Int("kept", len(kept)),
+		zap.Int("withdrawn", len(withdrawMsgs)),
+		zap.Int("topped_up", len(addMsgs)),
+		zap.Int("created", len(createMsgs)),
+	)
+
+	msgs := append(withdrawMsgs, addMsgs...)
+	return append(msgs, createMsgs...), nil
+}
+
+// addToPositionMsg adds tokens to an existing position
+func addToPositionMsg(pool types.ConcentratedPool, p model.FullPositionBreakdown, tokens sdk.Coins, addr string, slippage osmomath.Dec) (sdk.Msg, error) {
+	amount0 := tokens.AmountOf(pool.Token0)
+	amount1 := tokens.AmountOf(pool.Token1)
+
+	min0, min1, err := minAmounts(pool, p.Position.LowerTick, p.Position.UpperTick, amount0, amount1, slippage)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := cltypes.MsgAddToPosition{
+		PositionId:      p.Position.PositionId,
+		Sender:          addr,
+		Amount0:         amount0,
+		Amount1:         amount1,
+		TokenMinAmount0: min0,
+		TokenMinAmount1: min1,
+	}
+
+	return &msg, nil
+}
+
+// positionAssets returns the tokens held in a position
+func positionAssets(p model.FullPositionBreakdown) sdk.Coins {
+	return sdk.NewCoins(p.Asset0, p.Asset1)
+}
+
+// missingCoins returns the amounts in want that are not covered by have
+func missingCoins(want, have sdk.Coins) sdk.Coins {
+	var missing sdk.Coins
+	for _, c := range want {
+		if delta := c.Amount.Sub(have.AmountOf(c.Denom)); delta.IsPositive() {
+			missing = missing.Add(sdk.NewCoin(c.Denom, delta))
+		}
+	}
+	return missing
+}
+
+// clampCoins limits each amount in coins to the amount in limit
+func clampCoins(coins, limit sdk.Coins) sdk.Coins {
+	var clamped sdk.Coins
+	for _, c := range coins {
+		amount := sdk.MinInt(c.Amount, limit.AmountOf(c.Denom))
+		if amount.IsPositive() {
+			clamped = clamped.Add(sdk.NewCoin(c.Denom, amount))
+		}
+	}
+	return clamped
+}
@@ -0,0 +1,135 @@
+package liquidity
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/osmosis-labs/osmosis/osmomath"
+	model "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/model"
+	cltypes "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/types"
+	"go.uber.org/zap"
+	"gotest.tools/assert"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+func testPosition(id uint64, lowerTick, upperTick int64, amount0, amount1 int64) model.FullPositionBreakdown {
+	return model.FullPositionBreakdown{
+		Position: model.Position{
+			PositionId: id,
+			Address:    "osmo1bot",
+			LowerTick:  lowerTick,
+			UpperTick:  upperTick,
+			Liquidity:  sdk.OneDec(),
+		},
+		Asset0: sdk.NewInt64Coin("base", amount0),
+		Asset1: sdk.NewInt64Coin("quote", amount1),
+	}
+}
+
+func testPool() types.ConcentratedPool {
+	return types.ConcentratedPool{
+		ID:               1,
+		Token0:           "base",
+		Token1:           "quote",
+		TickSpacing:      100,
+		CurrentTick:      0,
+		CurrentSqrtPrice: osmomath.OneBigDec(),
+	}
+}
+
+func TestReconcileKeepsMatchingPositions(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	existing := []model.FullPositionBreakdown{
+		testPosition(1, -200, -100, 0, 100),
+		testPosition(2, 100, 200, 100, 0),
+	}
+	desired := []DesiredPosition{
+		{LowerTick: -200, UpperTick: -100, Tokens: sdk.NewCoins(sdk.NewInt64Coin("quote", 100))},
+		{LowerTick: 100, UpperTick: 200, Tokens: sdk.NewCoins(sdk.NewInt64Coin("base", 100))},
+	}
+
+	msgs, err := Reconcile(logger, testPool(), "osmo1bot", existing, desired, nil, osmomath.ZeroDec())
+	assert.NilError(t, err)
+
+	assert.Equal(t, 0, len(msgs))
+}
+
+func TestReconcileMovesChangedPositions(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	existing := []model.FullPositionBreakdown{
+		testPosition(1, -200, -100, 0, 100),
+		testPosition(2, 100, 200, 60, 40),
+	}
+	desired := []DesiredPosition{
+		{LowerTick: -200, UpperTick: -100, Tokens: sdk.NewCoins(sdk.NewInt64Coin("quote", 140))},
+		{LowerTick: 200, UpperTick: 300, Tokens: sdk.NewCoins(sdk.NewInt64Coin("base", 60))},
+	}
+
+	msgs, err := Reconcile(logger, testPool(), "osmo1bot", existing, desired, nil, osmomath.ZeroDec())
+	assert.NilError(t, err)
+
+	assert.Equal(t, 3, len(msgs))
+
+	withdraw, ok := msgs[0].(*cltypes.MsgWithdrawPosition)
+	assert.Assert(t, ok)
+	assert.Equal(t, uint64(2), withdraw.PositionId)
+
+	add, ok := msgs[1].(*cltypes.MsgAddToPosition)
+	assert.Assert(t, ok)
+	assert.Equal(t, uint64(1), add.PositionId)
+	assert.Equal(t, int64(0), add.Amount0.Int64())
+	assert.Equal(t, int64(40), add.Amount1.Int64())
+
+	create, ok := msgs[2].(*cltypes.MsgCreatePosition)
+	assert.Assert(t, ok)
+	assert.Equal(t, int64(200), create.LowerTick)
+	assert.Equal(t, int64(300), create.UpperTick)
+	assert.Equal(t, "60base", create.TokensProvided.String())
+}
+
+func TestReconcileLimitsToAvailableFunds(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	desired := []DesiredPosition{
+		{LowerTick: 100, UpperTick: 200, Tokens: sdk.NewCoins(sdk.NewInt64Coin("base", 100))},
+		{LowerTick: -200, UpperTick: -100, Tokens: sdk.NewCoins(sdk.NewInt64Coin("quote", 100))},
+	}
+	free := sdk.NewCoins(sdk.NewInt64Coin("base", 50))
+
+	msgs, err := Reconcile(logger, testPool(), "osmo1bot", nil, desired, free, osmomath.ZeroDec())
+	assert.NilError(t, err)
+
+	assert.Equal(t, 1, len(msgs))
+
+	create, ok := msgs[0].(*cltypes.MsgCreatePosition)
+	assert.Assert(t, ok)
+	assert.Equal(t, "50base", create.TokensProvided.String())
+}
+
+func TestReconcileSetsMinimumAmounts(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	desired := []DesiredPosition{
+		{LowerTick: 1000, UpperTick: 2000, Tokens: sdk.NewCoins(sdk.NewInt64Coin("base", 1000000))},
+		{LowerTick: -2000, UpperTick: -1000, Tokens: sdk.NewCoins(sdk.NewInt64Coin("quote", 1000000))},
+	}
+	free := sdk.NewCoins(sdk.NewInt64Coin("base", 1000000), sdk.NewInt64Coin("quote", 1000000))
+	slippage := osmomath.MustNewDecFromStr("0.01")
+
+	msgs, err := Reconcile(logger, testPool(), "osmo1bot", nil, desired, free, slippage)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(msgs))
+
+	sell, ok := msgs[0].(*cltypes.MsgCreatePosition)
+	assert.Assert(t, ok)
+	assert.Assert(t, sell.TokenMinAmount0.GTE(sdk.NewInt(989999)) && sell.TokenMinAmount0.LTE(sdk.NewInt(990000)))
+	assert.Equal(t, int64(0), sell.TokenMinAmount1.Int64())
+
+	buy, ok := msgs[1].(*cltypes.MsgCreatePosition)
+	assert.Assert(t, ok)
+	assert.Equal(t, int64(0), buy.TokenMinAmount0.Int64())
+	assert.Assert(t, buy.TokenMinAmount1.GTE(sdk.NewInt(989999)) && buy.TokenMinAmount1.LTE(sdk.NewInt(990000)))
+}
@@ -0,0 +1,89 @@
+package liquidity
+
+import (
+	sdkmath "cosmossdk.io/math"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+	cltypes "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/types"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+// MessageReport describes a position message and, when the transaction has
+// been simulated or executed, its result
+type MessageReport struct {
+	Type       string `json:"type"`
+	PositionID uint64 `json:"position_id,omitempty"`
+	LowerTick  int64  `json:"lower_tick,omitempty"`
+	UpperTick  int64  `json:"upper_tick,omitempty"`
+	Provided   string `json:"provided,omitempty"`
+	Liquidity  string `json:"liquidity,omitempty"`
+	Result     string `json:"result,omitempty"`
+}
+
+// ReportMessages describes the messages of a transaction on the pool. The
+// responses are the msg responses of the simulated or executed transaction in
+// the same order as the messages, and may be nil if there are none.
+func ReportMessages(pool types.ConcentratedPool, msgs []sdk.Msg, responses []*codectypes.Any) []MessageReport {
+	reports := make([]MessageReport, len(msgs))
+
+	for i, msg := range msgs {
+		report := MessageReport{Type: sdk.MsgTypeURL(msg)}
+
+		switch m := msg.(type) {
+		case *cltypes.MsgCreatePosition:
+			report.LowerTick = m.LowerTick
+			report.UpperTick = m.UpperTick
+			report.Provided = m.TokensProvided.String()
+		case *cltypes.MsgAddToPosition:
+			report.PositionID = m.PositionId
+			report.Provided = sdk.NewCoins(sdk.NewCoin(pool.Token0, m.Amount0), sdk.NewCoin(pool.Token1, m.Amount1)).String()
+		case *cltypes.MsgWithdrawPosition:
+			report.PositionID = m.PositionId
+			report.Liquidity = m.LiquidityAmount.String()
+		}
+
+		if i < len(responses) && responses[i] != nil {
+			reportResponse(pool, &report, responses[i])
+		}
+
+		reports[i] = report
+	}
+
+	return reports
+}
+
+// reportResponse adds the result of a message to its report
+func reportResponse(pool types.ConcentratedPool, report *MessageReport, response *codectypes.Any) {
+	switch response.TypeUrl {
+	case "/" + proto.MessageName(&cltypes.MsgCreatePositionResponse{}):
+		var r cltypes.MsgCreatePositionResponse
+		if err := r.Unmarshal(response.Value); err != nil {
+			return
+		}
+		report.PositionID = r.PositionId
+		report.LowerTick = r.LowerTick
+		report.UpperTick = r.UpperTick
+		report.Liquidity = r.LiquidityCreated.String()
+		report.Result = resultCoins(pool, r.Amount0, r.Amount1)
+	case "/" + proto.MessageName(&cltypes.MsgAddToPositionResponse{}):
+		var r cltypes.MsgAddToPositionResponse
+		if err := r.Unmarshal(response.Value); err != nil {
+			return
+		}
+		report.PositionID = r.PositionId
+		report.Result = resultCoins(pool, r.Amount0, r.Amount1)
+	case "/" + proto.MessageName(&cltypes.MsgWithdrawPositionResponse{}):
+		var r cltypes.MsgWithdrawPositionResponse
+		if err := r.Unmarshal(response.Value); err != nil {
+			return
+		}
+		report.Result = resultCoins(pool, r.Amount0, r.Amount1)
+	}
+}
+
+// resultCoins formats the token0 and token1 amounts of a result as coins
+func resultCoins(pool types.ConcentratedPool, amount0, amount1 sdkmath.Int) string {
+	return sdk.NewCoins(sdk.NewCoin(pool.Token0, amount0), sdk.NewCoin(pool.Token1, amount1)).String()
+}
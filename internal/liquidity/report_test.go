@@ -0,0 +1,52 @@
+package liquidity
+
+import (
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	cltypes "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/types"
+	"gotest.tools/assert"
+)
+
+func TestReportMessages(t *testing.T) {
+	msgs := []sdk.Msg{
+		&cltypes.MsgWithdrawPosition{PositionId: 1, Sender: "osmo1bot", LiquidityAmount: sdk.OneDec()},
+		&cltypes.MsgCreatePosition{
+			PoolId:         1,
+			Sender:         "osmo1bot",
+			LowerTick:      -200,
+			UpperTick:      -100,
+			TokensProvided: sdk.NewCoins(sdk.NewInt64Coin("quote", 100)),
+		},
+	}
+
+	createResponse, err := codectypes.NewAnyWithValue(&cltypes.MsgCreatePositionResponse{
+		PositionId:       2,
+		Amount0:          sdk.ZeroInt(),
+		Amount1:          sdk.NewInt(99),
+		LiquidityCreated: sdk.NewDec(5),
+		LowerTick:        -200,
+		UpperTick:        -100,
+	})
+	assert.NilError(t, err)
+
+	reports := ReportMessages(testPool(), msgs, []*codectypes.Any{nil, createResponse})
+
+	assert.DeepEqual(t, reports, []MessageReport{
+		{
+			Type:       "/osmosis.concentratedliquidity.v1beta1.MsgWithdrawPosition",
+			PositionID: 1,
+			Liquidity:  sdk.OneDec().String(),
+		},
+		{
+			Type:       "/osmosis.concentratedliquidity.v1beta1.MsgCreatePosition",
+			PositionID: 2,
+			LowerTick:  -200,
+			UpperTick:  -100,
+			Provided:   "100quote",
+			Liquidity:  sdk.NewDec(5).String(),
+			Result:     "99quote",
+		},
+	})
+}
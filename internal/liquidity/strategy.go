@@ -0,0 +1,102 @@
+package liquidity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	model "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/model"
+	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+// DefaultStrategy is used when no strategy is set in the config
+const DefaultStrategy = "market_make"
+
+// ErrNothingToDeploy is returned by a strategy that has no funds to place,
+// in which case the rebalance is skipped
+var ErrNothingToDeploy = errors.New("nothing to deploy")
+
+// MarketSnapshot is the state of the market a strategy uses to decide where
+// liquidity should be placed. All of it is read at the same block height.
+type MarketSnapshot struct {
+	Height              int64
+	Pool                types.ConcentratedPool
+	BaseSpotPrice       string
+	PowerSpotPrice      string
+	SpotPrice           string
+	TargetPrice         string
+	NormalisationFactor string
+	CurrentTick         int64
+	Positions           []model.FullPositionBreakdown
+	Balances            sdk.Coins
+}
+
+// DesiredPosition is a position that a strategy wants to have open in the pool.
+type DesiredPosition struct {
+	LowerTick int64
+	UpperTick int64
+	Tokens    sdk.Coins
+}
+
+// Strategy computes the set of positions the bot should hold for a snapshot.
+type Strategy interface {
+	Name() string
+	DesiredPositions(l *zap.Logger, s MarketSnapshot) ([]DesiredPosition, error)
+}
+
+// StrategyFactory builds a strategy from the bot configuration
+type StrategyFactory func(cfg *types.Config) (Strategy, error)
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]StrategyFactory{}
+)
+
+// RegisterStrategy makes a strategy available by name. It panics if the name
+// is already registered, so it is intended to be called from init functions.
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+
+	if _, ok := strategies[name]; ok {
+		panic(fmt.Sprintf("strategy %q already registered", name))
+	}
+
+	strategies[name] = factory
+}
+
+// NewStrategy builds the strategy registered under name, falling back to the
+// default strategy if name is empty.
+func NewStrategy(name string, cfg *types.Config) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+
+	strategiesMu.RLock()
+	factory, ok := strategies[name]
+	strategiesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, available strategies: %v", name, Strategies())
+	}
+
+	return factory(cfg)
+}
+
+// Strategies returns the sorted names of all registered strategies
+func Strategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,47 @@
+package liquidity
+
+import (
+	"errors"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"go.uber.org/zap"
+	"gotest.tools/assert"
+
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+func TestNewStrategyDefault(t *testing.T) {
+	strategy, err := NewStrategy("", &types.Config{})
+
+	assert.NilError(t, err)
+	assert.Equal(t, DefaultStrategy, strategy.Name())
+}
+
+func TestNewStrategyUnknown(t *testing.T) {
+	_, err := NewStrategy("does_not_exist", &types.Config{})
+
+	assert.ErrorContains(t, err, "unknown strategy")
+}
+
+func TestMarketMakeNothingToDeploy(t *testing.T) {
+	cfg := &types.Config{Position: types.Position{Spread: "0.05", UseWalletBalance: true}}
+	strategy, err := NewStrategy(DefaultStrategy, cfg)
+	assert.NilError(t, err)
+
+	snapshot := MarketSnapshot{
+		Pool:        testPool(),
+		SpotPrice:   "1",
+		TargetPrice: "1",
+		Balances:    sdk.NewCoins(sdk.NewInt64Coin("base", 1000)),
+	}
+
+	_, err = strategy.DesiredPositions(zap.NewNop(), snapshot)
+	assert.Assert(t, errors.Is(err, ErrNothingToDeploy))
+
+	msgs, spend, err := CreateUpdatePositionMsgs(zap.NewNop(), strategy, snapshot, "osmo1bot", osmomath.ZeroDec())
+	assert.Assert(t, errors.Is(err, ErrNothingToDeploy))
+	assert.Equal(t, len(msgs), 0)
+	assert.Assert(t, spend.Empty())
+}
@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const namespace = "flood"
+
+// Rebalance results
+const (
+	RebalanceSkipped   = "skipped"
+	RebalanceUnchanged = "unchanged"
+	RebalanceSimulated = "simulated"
+	RebalanceSubmitted = "submitted"
+	RebalanceFailed    = "failed"
+)
+
+// Transaction results
+const (
+	TxSuccess = "success"
+	TxFailure = "failure"
+)
+
+// Metrics holds the Prometheus metrics of the bot
+type Metrics struct {
+	registry *prometheus.Registry
+
+	markPrice           *prometheus.GaugeVec
+	targetPrice         *prometheus.GaugeVec
+	indexPrice          *prometheus.GaugeVec
+	premium             *prometheus.GaugeVec
+	normalisationFactor *prometheus.GaugeVec
+	currentTick         *prometheus.GaugeVec
+	positionLiquidity   *prometheus.GaugeVec
+	positionAmount      *prometheus.GaugeVec
+
+	eventsReceived  *prometheus.CounterVec
+	syntheticEvents *prometheus.CounterVec
+	eventsCoalesced *prometheus.CounterVec
+	triggers        *prometheus.CounterVec
+	rebalances      *prometheus.CounterVec
+	transactions    *prometheus.CounterVec
+
+	eventsPerTrigger *prometheus.HistogramVec
+	eventToBroadcast *prometheus.HistogramVec
+}
+
+// Market holds the market state of a pool at a rebalance
+type Market struct {
+	MarkPrice           float64
+	TargetPrice         float64
+	IndexPrice          float64
+	Premium             float64
+	NormalisationFactor float64
+	CurrentTick         int64
+}
+
+// Position holds the liquidity and token amounts of a position
+type Position struct {
+	ID        uint64
+	Liquidity float64
+	Amount0   float64
+	Amount1   float64
+}
+
+// New returns the metrics registered with a new registry
+func New() *Metrics {
+	poolGauge := func(name, help string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      name,
+			Help:      help,
+		}, []string{"pool_id"})
+	}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		markPrice:           poolGauge("mark_price", "Mark price of the power perpetual."),
+		targetPrice:         poolGauge("target_price", "Target price of the power perpetual."),
+		indexPrice:          poolGauge("index_price", "Index price of the power perpetual."),
+		premium:             poolGauge("premium", "Premium of the mark price over the index price."),
+		normalisationFactor: poolGauge("normalisation_factor", "Normalisation factor of the power perpetual."),
+		currentTick:         poolGauge("current_tick", "Current tick of the pool."),
+		positionLiquidity: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "position_liquidity",
+			Help:      "Liquidity of a position held by the bot.",
+		}, []string{"pool_id", "position_id"}),
+		positionAmount: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "position_amount",
+			Help:      "Amount of token0 or token1 in a position held by the bot.",
+		}, []string{"pool_id", "position_id", "token"}),
+
+		eventsReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_received_total",
+			Help:      "Events received from the websocket subscription.",
+		}, []string{"query"}),
+		syntheticEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "synthetic_events_total",
+			Help:      "Rebalance triggers emitted by the bot itself after a reconnect, on a timer or every number of blocks.",
+		}, []string{"trigger"}),
+		eventsCoalesced: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_coalesced_total",
+			Help:      "Events collapsed into the rebalance trigger of an earlier event.",
+		}, []string{"pool_id"}),
+		triggers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rebalance_triggers_total",
+			Help:      "Rebalance triggers handled after coalescing events.",
+		}, []string{"pool_id"}),
+		rebalances: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rebalances_total",
+			Help:      "Rebalances by result.",
+		}, []string{"pool_id", "result"}),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transactions_total",
+			Help:      "Broadcast transactions by result.",
+		}, []string{"pool_id", "result"}),
+
+		eventsPerTrigger: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "events_per_trigger",
+			Help:      "Events collapsed into each rebalance trigger.",
+			Buckets:   []float64{1, 2, 5, 10, 20, 50, 100},
+		}, []string{"pool_id"}),
+		eventToBroadcast: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "event_to_broadcast_seconds",
+			Help:      "Time from receiving an event to broadcasting the transaction for it.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"pool_id"}),
+	}
+
+	m.registry.MustRegister(
+		m.markPrice,
+		m.targetPrice,
+		m.indexPrice,
+		m.premium,
+		m.normalisationFactor,
+		m.currentTick,
+		m.positionLiquidity,
+		m.positionAmount,
+		m.eventsReceived,
+		m.syntheticEvents,
+		m.eventsCoalesced,
+		m.triggers,
+		m.rebalances,
+		m.transactions,
+		m.eventsPerTrigger,
+		m.eventToBroadcast,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// SetMarket records the market state of a pool
+func (m *Metrics) SetMarket(poolID uint64, market Market) {
+	pool := poolLabel(poolID)
+
+	m.markPrice.WithLabelValues(pool).Set(market.MarkPrice)
+	m.targetPrice.WithLabelValues(pool).Set(market.TargetPrice)
+	m.indexPrice.WithLabelValues(pool).Set(market.IndexPrice)
+	m.premium.WithLabelValues(pool).Set(market.Premium)
+	m.normalisationFactor.WithLabelValues(pool).Set(market.NormalisationFactor)
+	m.currentTick.WithLabelValues(pool).Set(float64(market.CurrentTick))
+}
+
+// SetPositions replaces the positions recorded for a pool
+func (m *Metrics) SetPositions(poolID uint64, positions []Position) {
+	pool := poolLabel(poolID)
+
+	m.positionLiquidity.DeletePartialMatch(prometheus.Labels{"pool_id": pool})
+	m.positionAmount.DeletePartialMatch(prometheus.Labels{"pool_id": pool})
+
+	for _, p := range positions {
+		id := strconv.FormatUint(p.ID, 10)
+
+		m.positionLiquidity.WithLabelValues(pool, id).Set(p.Liquidity)
+		m.positionAmount.WithLabelValues(pool, id, "token0").Set(p.Amount0)
+		m.positionAmount.WithLabelValues(pool, id, "token1").Set(p.Amount1)
+	}
+}
+
+// EventReceived counts an event received for the query
+func (m *Metrics) EventReceived(query string) {
+	m.eventsReceived.WithLabelValues(query).Inc()
+}
+
+// SyntheticEvent counts a rebalance trigger emitted by the bot itself
+func (m *Metrics) SyntheticEvent(trigger string) {
+	m.syntheticEvents.WithLabelValues(trigger).Inc()
+}
+
+// Trigger counts a rebalance trigger of a pool that collapsed the given
+// number of events
+func (m *Metrics) Trigger(poolID uint64, events int) {
+	pool := poolLabel(poolID)
+
+	m.triggers.WithLabelValues(pool).Inc()
+	m.eventsPerTrigger.WithLabelValues(pool).Observe(float64(events))
+	if events > 1 {
+		m.eventsCoalesced.WithLabelValues(pool).Add(float64(events - 1))
+	}
+}
+
+// Rebalance counts a rebalance of a pool with the result
+func (m *Metrics) Rebalance(poolID uint64, result string) {
+	m.rebalances.WithLabelValues(poolLabel(poolID), result).Inc()
+}
+
+// Transaction counts a transaction for a pool with the result
+func (m *Metrics) Transaction(poolID uint64, result string) {
+	m.transactions.WithLabelValues(poolLabel(poolID), result).Inc()
+}
+
+// Broadcast records the time from receiving an event to broadcasting the
+// transaction for it
+func (m *Metrics) Broadcast(poolID uint64, receivedAt time.Time) {
+	m.eventToBroadcast.WithLabelValues(poolLabel(poolID)).Observe(time.Since(receivedAt).Seconds())
+}
+
+// Serve serves the handler on the address until the context is done
+func Serve(ctx context.Context, l *zap.Logger, address string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			l.Debug("Failed to shut down HTTP server", zap.Error(err))
+		}
+	}()
+
+	l.Info("Serving HTTP", zap.String("address", address))
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		l.Error("HTTP server failed", zap.Error(err))
+	}
+}
+
+func poolLabel(poolID uint64) string {
+	return strconv.FormatUint(poolID, 10)
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NilError(t, err)
+
+	return string(body)
+}
+
+func TestMetricsScrape(t *testing.T) {
+	m := New()
+
+	m.SetMarket(1299, Market{
+		MarkPrice:           12.5,
+		TargetPrice:         12,
+		IndexPrice:          11.75,
+		Premium:             0.02,
+		NormalisationFactor: 0.98,
+		CurrentTick:         -4200,
+	})
+	m.SetPositions(1299, []Position{{ID: 7, Liquidity: 1000, Amount0: 10, Amount1: 20}})
+	m.EventReceived("token_swapped.pool_id = '1299'")
+	m.SyntheticEvent("timer")
+	m.Trigger(1299, 20)
+	m.Trigger(1299, 1)
+	m.Rebalance(1299, RebalanceSubmitted)
+	m.Transaction(1299, TxSuccess)
+	m.Transaction(1299, TxFailure)
+	m.Transaction(1299, TxFailure)
+	m.Broadcast(1299, time.Now().Add(-time.Second))
+
+	body := scrape(t, m)
+
+	for _, line := range []string{
+		`flood_mark_price{pool_id="1299"} 12.5`,
+		`flood_target_price{pool_id="1299"} 12`,
+		`flood_index_price{pool_id="1299"} 11.75`,
+		`flood_premium{pool_id="1299"} 0.02`,
+		`flood_normalisation_factor{pool_id="1299"} 0.98`,
+		`flood_current_tick{pool_id="1299"} -4200`,
+		`flood_position_liquidity{pool_id="1299",position_id="7"} 1000`,
+		`flood_position_amount{pool_id="1299",position_id="7",token="token0"} 10`,
+		`flood_position_amount{pool_id="1299",position_id="7",token="token1"} 20`,
+		`flood_events_received_total{query="token_swapped.pool_id = '1299'"} 1`,
+		`flood_synthetic_events_total{trigger="timer"} 1`,
+		`flood_events_coalesced_total{pool_id="1299"} 19`,
+		`flood_rebalance_triggers_total{pool_id="1299"} 2`,
+		`flood_events_per_trigger_sum{pool_id="1299"} 21`,
+		`flood_rebalances_total{pool_id="1299",result="submitted"} 1`,
+		`flood_transactions_total{pool_id="1299",result="success"} 1`,
+		`flood_transactions_total{pool_id="1299",result="failure"} 2`,
+		`flood_event_to_broadcast_seconds_count{pool_id="1299"} 1`,
+	} {
+		assert.Assert(t, strings.Contains(body, line+"\n"), "missing %q", line)
+	}
+}
+
+func TestMetricsSetPositionsReplaces(t *testing.T) {
+	m := New()
+
+	m.SetPositions(1, []Position{{ID: 1, Liquidity: 1}, {ID: 2, Liquidity: 2}})
+	m.SetPositions(2, []Position{{ID: 3, Liquidity: 3}})
+	m.SetPositions(1, []Position{{ID: 4, Liquidity: 4}})
+
+	body := scrape(t, m)
+
+	assert.Assert(t, !strings.Contains(body, `position_id="1"`))
+	assert.Assert(t, !strings.Contains(body, `position_id="2"`))
+	assert.Assert(t, strings.Contains(body, `flood_position_liquidity{pool_id="2",position_id="3"} 3`))
+	assert.Assert(t, strings.Contains(body, `flood_position_liquidity{pool_id="1",position_id="4"} 4`))
+}
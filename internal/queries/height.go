@@ -0,0 +1,20 @@
+package queries
+
+import (
+	"context"
+	"strconv"
+
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// AtHeight returns a context whose gRPC queries read the state at the block
+// height, so that queries made with it see a consistent snapshot. A height of
+// zero leaves the queries reading the latest state.
+func AtHeight(ctx context.Context, height int64) context.Context {
+	if height <= 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatInt(height, 10))
+}
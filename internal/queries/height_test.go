@@ -0,0 +1,24 @@
+package queries
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"gotest.tools/assert"
+)
+
+func TestAtHeight(t *testing.T) {
+	ctx := AtHeight(context.Background(), 12345)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, md.Get("x-cosmos-block-height"), []string{"12345"})
+}
+
+func TestAtHeightLatest(t *testing.T) {
+	ctx := AtHeight(context.Background(), 0)
+
+	_, ok := metadata.FromOutgoingContext(ctx)
+	assert.Assert(t, !ok)
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"sync"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/osmosis-labs/osmosis/osmomath"
 	"github.com/osmosis-labs/osmosis/v21/tests/e2e/util"
 	cl "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/client/queryproto"
-	cltypes "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/types"
+	model "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/model"
 	poolmanager "github.com/osmosis-labs/osmosis/v21/x/poolmanager/client/queryproto"
 	pmtypes "github.com/osmosis-labs/osmosis/v21/x/poolmanager/types"
+	txfeestypes "github.com/osmosis-labs/osmosis/v21/x/txfees/types"
 
 	"github.com/margined-protocol/flood/internal/types"
 )
@@ -29,6 +33,27 @@ func GetUserPositions(ctx context.Context, client cl.QueryClient, poolConfig typ
 	return userPositions, nil
 }
 
+// GetBalances returns the free wallet balances of the user
+func GetBalances(ctx context.Context, client banktypes.QueryClient, user string) (sdk.Coins, error) {
+	res, err := client.AllBalances(ctx, &banktypes.QueryAllBalancesRequest{Address: user})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Balances, nil
+}
+
+// GetEipBaseFee returns the EIP-1559 base fee per unit of gas of the txfees
+// module, denominated in the base denom of the chain
+func GetEipBaseFee(ctx context.Context, client txfeestypes.QueryClient) (osmomath.Dec, error) {
+	res, err := client.GetEipBaseFee(ctx, &txfeestypes.QueryEipBaseFeeRequest{})
+	if err != nil {
+		return osmomath.Dec{}, err
+	}
+
+	return res.BaseFee, nil
+}
+
 func GetSpotPrice(ctx context.Context, client poolmanager.QueryClient, poolConfig types.Pool) (string, error) {
 	req := poolmanager.SpotPriceRequest{
 		PoolId:          poolConfig.ID,
@@ -46,24 +71,44 @@ func GetSpotPrice(ctx context.Context, client poolmanager.QueryClient, poolConfi
 	return spotPrice.SpotPrice, nil
 }
 
-func GetCurrentTick(ctx context.Context, client poolmanager.QueryClient, poolId uint64) (int64, error) {
-
+// GetConcentratedPool returns the parameters and current state of a
+// concentrated liquidity pool
+func GetConcentratedPool(ctx context.Context, client poolmanager.QueryClient, poolId uint64) (types.ConcentratedPool, error) {
 	poolReq := poolmanager.PoolRequest{PoolId: poolId}
 	res, err := client.Pool(ctx, &poolReq)
 	if err != nil {
-		return 0, err
+		return types.ConcentratedPool{}, err
 	}
 
 	var pool pmtypes.PoolI
 	err = util.Cdc.UnpackAny(res.Pool, &pool)
 	if err != nil {
-		return 0, err
+		return types.ConcentratedPool{}, err
+	}
+
+	clPool, ok := pool.(*model.Pool)
+	if !ok {
+		return types.ConcentratedPool{}, fmt.Errorf("pool %d is not a concentrated liquidity pool", poolId)
 	}
 
-	currentTick := pool.(cltypes.ConcentratedPoolExtension).GetCurrentTick()
+	return types.ConcentratedPool{
+		ID:               clPool.Id,
+		Token0:           clPool.Token0,
+		Token1:           clPool.Token1,
+		TickSpacing:      int64(clPool.TickSpacing),
+		SpreadFactor:     clPool.SpreadFactor.String(),
+		CurrentTick:      clPool.CurrentTick,
+		CurrentSqrtPrice: clPool.CurrentSqrtPrice,
+	}, nil
+}
 
-	return currentTick, nil
+func GetCurrentTick(ctx context.Context, client poolmanager.QueryClient, poolId uint64) (int64, error) {
+	pool, err := GetConcentratedPool(ctx, client, poolId)
+	if err != nil {
+		return 0, err
+	}
 
+	return pool.CurrentTick, nil
 }
 
 func GetTotalPoolLiquidity(ctx context.Context, client poolmanager.QueryClient, poolId uint64) (*poolmanager.TotalPoolLiquidityResponse, error) {
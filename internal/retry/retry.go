@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Policy controls how often and how quickly an operation is retried
+type Policy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+type retryableError struct{ err error }
+
+func (e retryableError) Error() string { return e.err.Error() }
+func (e retryableError) Unwrap() error { return e.err }
+
+// Permanent marks an error as not worth retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// Retryable marks an error as transient
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return retryableError{err}
+}
+
+// IsRetryable classifies an error as transient. Errors explicitly marked with
+// Retryable or Permanent are classified accordingly, otherwise transient gRPC
+// status codes, network errors and timeouts are retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+
+	var retryable retryableError
+	if errors.As(err, &retryable) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, the
+// attempts are exhausted or the context is done. The backoff doubles after
+// every attempt up to the maximum. onRetry, if set, is called before waiting.
+func Do(ctx context.Context, p Policy, fn func() error, onRetry func(attempt int, err error, backoff time.Duration)) error {
+	backoff := p.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt >= p.Attempts {
+			return err
+		}
+
+		if onRetry != nil {
+			onRetry(attempt, err, backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	assert.Equal(t, false, IsRetryable(nil))
+	assert.Equal(t, false, IsRetryable(errors.New("invalid spread")))
+	assert.Equal(t, true, IsRetryable(status.Error(codes.Unavailable, "connection refused")))
+	assert.Equal(t, true, IsRetryable(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	assert.Equal(t, false, IsRetryable(status.Error(codes.InvalidArgument, "bad request")))
+	assert.Equal(t, true, IsRetryable(Retryable(errors.New("no healthy endpoint"))))
+	assert.Equal(t, false, IsRetryable(Permanent(status.Error(codes.Unavailable, "connection refused"))))
+}
+
+func TestDoRetriesTransientErrors(t *testing.T) {
+	policy := Policy{Attempts: 3, InitialBackoff: time.Millisecond}
+
+	calls := 0
+	err := Do(context.Background(), policy, func() error {
+		calls++
+		if calls < 3 {
+			return status.Error(codes.Unavailable, "connection refused")
+		}
+		return nil
+	}, nil)
+
+	assert.NilError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDoStopsOnPermanentErrors(t *testing.T) {
+	policy := Policy{Attempts: 3, InitialBackoff: time.Millisecond}
+
+	calls := 0
+	err := Do(context.Background(), policy, func() error {
+		calls++
+		return errors.New("invalid spread")
+	}, nil)
+
+	assert.ErrorContains(t, err, "invalid spread")
+	assert.Equal(t, 1, calls)
+}
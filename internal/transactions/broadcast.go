@@ -0,0 +1,311 @@
+package transactions
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	sdkclient "github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ignite/cli/ignite/pkg/cosmosaccount"
+	"github.com/ignite/cli/ignite/pkg/cosmosclient"
+	"go.uber.org/zap"
+
+	"github.com/margined-protocol/flood/internal/queries"
+	"github.com/margined-protocol/flood/internal/types"
+)
+
+// pollInterval is how often a broadcast transaction is looked up while
+// waiting for it to be included in a block
+const pollInterval = time.Second
+
+// rpcInternalErrorCode is the JSON-RPC code of errors returned by an RPC
+// method, such as a transaction that is not found
+const rpcInternalErrorCode = -32603
+
+// TxError is returned for a transaction that was rejected in CheckTx or
+// failed when executed in a block
+type TxError struct {
+	TxHash    string
+	Codespace string
+	Code      uint32
+	Log       string
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("tx %s failed with code %d (%s): %s", e.TxHash, e.Code, e.Codespace, e.Log)
+}
+
+// Broadcaster signs and broadcasts transactions for the signer account,
+// tracking the account sequence locally
+type Broadcaster struct {
+	l        *zap.Logger
+	cfg      *types.Config
+	account  cosmosaccount.Account
+	memo     string
+	sequence *SequenceManager
+
+	// mu serialises broadcasts from the signer so that concurrent
+	// transactions are signed with consecutive sequences
+	mu *sync.Mutex
+
+	// height holds the height of the latest confirmed transaction of the
+	// signer
+	height *atomic.Int64
+
+	// funds serialises planning against the signer's balance per denom and
+	// holds the funds of unconfirmed transactions
+	funds *Funds
+}
+
+// NewBroadcaster returns a broadcaster that signs with the account and
+// attaches the memo to every transaction
+func NewBroadcaster(l *zap.Logger, cfg *types.Config, account cosmosaccount.Account, memo string) *Broadcaster {
+	return &Broadcaster{
+		l:        l,
+		cfg:      cfg,
+		account:  account,
+		memo:     memo,
+		sequence: NewSequenceManager(),
+		mu:       &sync.Mutex{},
+		height:   &atomic.Int64{},
+		funds:    NewFunds(),
+	}
+}
+
+// WithMemo returns a broadcaster for the same signer that attaches a
+// different memo. The account sequence, confirmed height and funds are
+// shared with the original.
+func (b *Broadcaster) WithMemo(memo string) *Broadcaster {
+	c := *b
+	c.memo = memo
+	return &c
+}
+
+// Confirmed records the height a transaction of the signer was included at
+func (b *Broadcaster) Confirmed(height int64) {
+	for {
+		current := b.height.Load()
+		if height <= current || b.height.CompareAndSwap(current, height) {
+			return
+		}
+	}
+}
+
+// ConfirmedHeight returns the height of the latest confirmed transaction of
+// the signer, or 0. State read below it does not reflect that transaction.
+func (b *Broadcaster) ConfirmedHeight() int64 {
+	return b.height.Load()
+}
+
+// Funds returns the use of the signer's funds, shared by every pool the
+// signer manages
+func (b *Broadcaster) Funds() *Funds {
+	return b.funds
+}
+
+// Broadcast signs the messages and submits them to the mempool through the
+// client. It returns once the transaction has passed CheckTx, without waiting
+// for it to be included in a block. A transaction rejected for an account
+// sequence mismatch is retried once after syncing the sequence from chain.
+func (b *Broadcaster) Broadcast(ctx context.Context, clients types.BlockchainClients, msgs ...sdk.Msg) (*sdk.TxResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	resp, err := b.broadcast(ctx, clients, msgs)
+	if !IsSequenceMismatch(err) {
+		return resp, err
+	}
+
+	b.l.Warn("Account sequence mismatch, resyncing and retrying", zap.Error(err))
+
+	b.sequence.Reset()
+
+	return b.broadcast(ctx, clients, msgs)
+}
+
+func (b *Broadcaster) broadcast(ctx context.Context, clients types.BlockchainClients, msgs []sdk.Msg) (*sdk.TxResponse, error) {
+	client := clients.CosmosClient
+
+	clientCtx, err := b.clientContext(client)
+	if err != nil {
+		return nil, err
+	}
+
+	from := clientCtx.GetFromAddress()
+
+	accountNumber, sequence, err := b.sequence.Next(func() (uint64, uint64, error) {
+		return clientCtx.AccountRetriever.GetAccountNumberSequence(clientCtx, from)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching account sequence: %w", err)
+	}
+
+	txf := client.TxFactory.
+		WithAccountNumber(accountNumber).
+		WithSequence(sequence).
+		WithMemo(b.memo)
+
+	var gasUsed uint64
+	if b.simulatesGas() {
+		simRes, _, err := tx.CalculateGas(clientCtx, txf, msgs...)
+		if err != nil {
+			return nil, fmt.Errorf("simulating transaction: %w", err)
+		}
+		gasUsed = simRes.GasInfo.GasUsed
+	}
+
+	gas, fees, err := b.GasAndFees(ctx, clients, gasUsed)
+	if err != nil {
+		return nil, err
+	}
+
+	txf = txf.
+		WithGas(gas).
+		WithFees(fees)
+
+	txBuilder, err := txf.BuildUnsignedTx(msgs...)
+	if err != nil {
+		return nil, fmt.Errorf("building transaction: %w", err)
+	}
+
+	if err := tx.Sign(txf, b.account.Name, txBuilder, true); err != nil {
+		return nil, fmt.Errorf("signing transaction: %w", err)
+	}
+
+	txBytes, err := clientCtx.TxConfig.TxEncoder()(txBuilder.GetTx())
+	if err != nil {
+		return nil, fmt.Errorf("encoding transaction: %w", err)
+	}
+
+	resp, err := clientCtx.BroadcastTxSync(txBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Code != 0 {
+		return resp, &TxError{TxHash: resp.TxHash, Codespace: resp.Codespace, Code: resp.Code, Log: resp.RawLog}
+	}
+
+	// The transaction holds the sequence once it is in the mempool
+	b.sequence.Increment()
+
+	return resp, nil
+}
+
+// GasAndFees returns the gas limit and the fees to send a transaction with,
+// given the gas it used in simulation. In the fixed fee mode the configured
+// fees are paid. In the dynamic fee mode the simulated gas is scaled by the
+// gas adjustment and the base fee is paid for it, up to the max fee. The
+// configured fees are paid if the base fee cannot be queried.
+func (b *Broadcaster) GasAndFees(ctx context.Context, clients types.BlockchainClients, gasUsed uint64) (uint64, string, error) {
+	switch b.cfg.FeeMode {
+	case "", FeeModeFixed:
+		gas, err := GasLimit(b.cfg.Gas, b.cfg.GasAdjustment, gasUsed)
+		if err != nil {
+			return 0, "", fmt.Errorf("parsing gas: %w", err)
+		}
+		return gas, b.cfg.Fees, nil
+	case FeeModeDynamic:
+		gas := adjustGas(b.cfg.GasAdjustment, gasUsed)
+
+		maxFee, err := sdk.ParseCoinNormalized(b.cfg.MaxFee)
+		if err != nil {
+			return 0, "", fmt.Errorf("parsing max fee: %w", err)
+		}
+
+		baseFee, err := queries.GetEipBaseFee(ctx, clients.TxFeesClient)
+		if err != nil {
+			b.l.Warn("Failed to query base fee, paying fixed fees",
+				zap.String("fees", b.cfg.Fees),
+				zap.Error(err),
+			)
+			return gas, b.cfg.Fees, nil
+		}
+
+		fee, err := DynamicFee(baseFee, b.cfg.BaseFeeMultiplier, gas, maxFee)
+		if err != nil {
+			return 0, "", err
+		}
+
+		b.l.Debug("Dynamic fee",
+			zap.String("base_fee", baseFee.String()),
+			zap.Uint64("gas", gas),
+			zap.String("fee", fee.String()),
+		)
+
+		return gas, fee.String(), nil
+	default:
+		return 0, "", fmt.Errorf("unknown fee mode %q", b.cfg.FeeMode)
+	}
+}
+
+// clientContext returns the context of the client for the signer
+func (b *Broadcaster) clientContext(client *cosmosclient.Client) (sdkclient.Context, error) {
+	from, err := b.account.Record.GetAddress()
+	if err != nil {
+		return sdkclient.Context{}, err
+	}
+
+	return client.Context().
+		WithFromName(b.account.Name).
+		WithFromAddress(from), nil
+}
+
+// simulatesGas reports whether the gas limit is estimated by simulation
+func (b *Broadcaster) simulatesGas() bool {
+	return b.cfg.FeeMode == FeeModeDynamic || b.cfg.Gas == "" || b.cfg.Gas == cosmosclient.GasAuto
+}
+
+// WaitForTx polls for a transaction until it has been included in a block
+// or the context is done. Transient RPC errors keep the polling going, so a
+// transaction already in the mempool is not given up on. A transaction that
+// failed when executed is returned together with a TxError.
+func WaitForTx(ctx context.Context, client *cosmosclient.Client, hash string) (*ctypes.ResultTx, error) {
+	bz, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, fmt.Errorf("decoding tx hash %q: %w", hash, err)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		res, err := client.RPC.Tx(ctx, bz, false)
+		if err == nil {
+			if res.TxResult.Code != 0 {
+				return res, &TxError{TxHash: hash, Codespace: res.TxResult.Codespace, Code: res.TxResult.Code, Log: res.TxResult.Log}
+			}
+			return res, nil
+		}
+
+		if !txPending(err) {
+			return nil, fmt.Errorf("fetching tx %s: %w", hash, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for tx %s: %w (last error: %v)", hash, ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// txPending reports whether looking up a transaction may succeed when polled
+// again. The node answers with an internal error for a transaction it has
+// not indexed yet, and errors reaching the node are transient. Any other
+// error answered by the node is final.
+func txPending(err error) bool {
+	var rpcErr *rpctypes.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == rpcInternalErrorCode
+	}
+	return true
+}
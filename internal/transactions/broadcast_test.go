@@ -0,0 +1,73 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	"github.com/ignite/cli/ignite/pkg/cosmosaccount"
+	"github.com/ignite/cli/ignite/pkg/cosmosclient"
+	"gotest.tools/assert"
+)
+
+// txRPC answers transaction lookups with the errors in turn, then with the
+// transaction
+type txRPC struct {
+	rpcclient.Client
+	errs []error
+}
+
+func (r *txRPC) Tx(_ context.Context, _ []byte, _ bool) (*ctypes.ResultTx, error) {
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return nil, err
+	}
+	return &ctypes.ResultTx{Height: 12, TxResult: abci.ResponseDeliverTx{}}, nil
+}
+
+func TestTxPending(t *testing.T) {
+	notFound := &rpctypes.RPCError{Code: -32603, Message: "Internal error", Data: "tx (AB) not found"}
+	invalid := &rpctypes.RPCError{Code: -32602, Message: "Invalid params"}
+
+	assert.Equal(t, txPending(fmt.Errorf("post failed: %w", notFound)), true)
+	assert.Equal(t, txPending(errors.New("post failed: connection refused")), true)
+	assert.Equal(t, txPending(invalid), false)
+}
+
+func TestWaitForTxPollsThroughTransientErrors(t *testing.T) {
+	client := &cosmosclient.Client{RPC: &txRPC{errs: []error{errors.New("connection reset by peer")}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := WaitForTx(ctx, client, "AB")
+	assert.NilError(t, err)
+	assert.Equal(t, res.Height, int64(12))
+}
+
+func TestWaitForTxStopsOnFinalErrors(t *testing.T) {
+	client := &cosmosclient.Client{RPC: &txRPC{errs: []error{&rpctypes.RPCError{Code: -32602, Message: "Invalid params"}}}}
+
+	_, err := WaitForTx(context.Background(), client, "AB")
+	assert.ErrorContains(t, err, "Invalid params")
+}
+
+func TestConfirmedHeightIsSharedAndOnlyRises(t *testing.T) {
+	b := NewBroadcaster(nil, nil, cosmosaccount.Account{}, "")
+	pool := b.WithMemo("pool:2")
+
+	b.Confirmed(10)
+	pool.Confirmed(8)
+	assert.Equal(t, b.ConfirmedHeight(), int64(10))
+	assert.Equal(t, pool.ConfirmedHeight(), int64(10))
+
+	pool.Confirmed(12)
+	assert.Equal(t, b.ConfirmedHeight(), int64(12))
+}
@@ -0,0 +1,66 @@
+package transactions
+
+import (
+	"fmt"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ignite/cli/ignite/pkg/cosmosclient"
+	"github.com/osmosis-labs/osmosis/osmomath"
+)
+
+const (
+	// FeeModeFixed pays the configured fees with the configured gas limit
+	FeeModeFixed = "fixed"
+
+	// FeeModeDynamic pays the EIP-1559 base fee of the txfees module for a
+	// gas limit estimated by simulation
+	FeeModeDynamic = "dynamic"
+
+	// defaultBaseFeeMultiplier leaves room for the base fee to rise before
+	// the transaction is included
+	defaultBaseFeeMultiplier = 1.1
+)
+
+// GasLimit returns the gas limit a transaction is sent with. A numeric gas
+// setting is used as is, while "auto" or an empty setting estimates the
+// limit from the simulated gas and the gas adjustment.
+func GasLimit(gas string, gasAdjustment float64, gasUsed uint64) (uint64, error) {
+	if gas != "" && gas != cosmosclient.GasAuto {
+		return strconv.ParseUint(gas, 10, 64)
+	}
+
+	return adjustGas(gasAdjustment, gasUsed), nil
+}
+
+// adjustGas scales the simulated gas by the gas adjustment
+func adjustGas(gasAdjustment float64, gasUsed uint64) uint64 {
+	if gasAdjustment <= 0 {
+		gasAdjustment = 1
+	}
+
+	return uint64(gasAdjustment * float64(gasUsed))
+}
+
+// DynamicFee returns the fee for the gas limit at the base fee scaled by the
+// multiplier, rounded up and paid in the denom of the max fee. An error is
+// returned if the fee exceeds the max fee.
+func DynamicFee(baseFee osmomath.Dec, multiplier float64, gas uint64, maxFee sdk.Coin) (sdk.Coin, error) {
+	if multiplier <= 0 {
+		multiplier = defaultBaseFeeMultiplier
+	}
+
+	multiplierDec, err := osmomath.NewDecFromStr(strconv.FormatFloat(multiplier, 'f', -1, 64))
+	if err != nil {
+		return sdk.Coin{}, fmt.Errorf("invalid base fee multiplier %v: %w", multiplier, err)
+	}
+
+	amount := baseFee.Mul(multiplierDec).MulInt64(int64(gas)).Ceil().TruncateInt()
+	fee := sdk.NewCoin(maxFee.Denom, amount)
+
+	if fee.Amount.GT(maxFee.Amount) {
+		return fee, fmt.Errorf("fee %s for %d gas at base fee %s exceeds max fee %s", fee, gas, baseFee, maxFee)
+	}
+
+	return fee, nil
+}
@@ -0,0 +1,53 @@
+package transactions
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/osmosis-labs/osmosis/osmomath"
+	"gotest.tools/assert"
+)
+
+func TestGasLimit(t *testing.T) {
+	gas, err := GasLimit("250000", 1.3, 100000)
+	assert.NilError(t, err)
+	assert.Equal(t, gas, uint64(250000))
+
+	gas, err = GasLimit("auto", 1.5, 100000)
+	assert.NilError(t, err)
+	assert.Equal(t, gas, uint64(150000))
+
+	gas, err = GasLimit("", 0, 100000)
+	assert.NilError(t, err)
+	assert.Equal(t, gas, uint64(100000))
+
+	_, err = GasLimit("lots", 1, 100000)
+	assert.ErrorContains(t, err, "invalid syntax")
+}
+
+func TestDynamicFee(t *testing.T) {
+	baseFee := osmomath.MustNewDecFromStr("0.0025")
+	maxFee := sdk.NewInt64Coin("uosmo", 10000)
+
+	fee, err := DynamicFee(baseFee, 1, 300000, maxFee)
+	assert.NilError(t, err)
+	assert.Equal(t, fee.String(), "750uosmo")
+
+	// Fees are rounded up
+	fee, err = DynamicFee(baseFee, 1.1, 300001, maxFee)
+	assert.NilError(t, err)
+	assert.Equal(t, fee.String(), "826uosmo")
+
+	// The multiplier defaults when unset
+	fee, err = DynamicFee(baseFee, 0, 300000, maxFee)
+	assert.NilError(t, err)
+	assert.Equal(t, fee.String(), "825uosmo")
+}
+
+func TestDynamicFeeExceedsMaxFee(t *testing.T) {
+	baseFee := osmomath.MustNewDecFromStr("0.1")
+	maxFee := sdk.NewInt64Coin("uosmo", 10000)
+
+	_, err := DynamicFee(baseFee, 1, 300000, maxFee)
+	assert.ErrorContains(t, err, "exceeds max fee 10000uosmo")
+}
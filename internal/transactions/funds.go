@@ -0,0 +1,104 @@
+package transactions
+
+import (
+	"sort"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Funds tracks the use of the signer's funds per denom. Pools that share the
+// signer plan their transactions against its wallet balance, so two pools
+// with a denom in common must not plan at the same time or both commit the
+// same funds. Pools without a denom in common are not held up.
+//
+// A pool locks its denoms while it reads the balance, plans and submits its
+// transaction, and reserves the funds the transaction may spend until it has
+// confirmed. Other pools plan against the balance net of the reservations
+// instead of waiting for the confirmation.
+type Funds struct {
+	mu       sync.Mutex
+	locks    map[string]*sync.Mutex
+	reserved sdk.Coins
+}
+
+// NewFunds returns funds without locks or reservations
+func NewFunds() *Funds {
+	return &Funds{locks: make(map[string]*sync.Mutex)}
+}
+
+// Lock locks the denoms, waiting for any holder of one of them, and returns
+// the function that unlocks them. Denoms are locked in sorted order so that
+// holders of overlapping sets cannot deadlock.
+func (f *Funds) Lock(denoms ...string) (unlock func()) {
+	sorted := append([]string(nil), denoms...)
+	sort.Strings(sorted)
+
+	var held []*sync.Mutex
+	for i, denom := range sorted {
+		if i > 0 && denom == sorted[i-1] {
+			continue
+		}
+
+		lock := f.lock(denom)
+		lock.Lock()
+		held = append(held, lock)
+	}
+
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Unlock()
+		}
+	}
+}
+
+// Reserve sets the coins aside for an unconfirmed transaction and returns the
+// function that releases them. It must be called with the denoms of the coins
+// locked. Releasing locks the denoms itself, so that a pool planning at the
+// same time either sees the reservation or the confirmed transaction, and
+// only releases the coins once.
+func (f *Funds) Reserve(coins sdk.Coins) (release func()) {
+	f.mu.Lock()
+	f.reserved = f.reserved.Add(coins...)
+	f.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unlock := f.Lock(coins.Denoms()...)
+			defer unlock()
+
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.reserved = f.reserved.Sub(coins...)
+		})
+	}
+}
+
+// Available returns the balances less the reserved funds, leaving out denoms
+// that are fully reserved
+func (f *Funds) Available(balances sdk.Coins) sdk.Coins {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var available sdk.Coins
+	for _, coin := range balances {
+		if amount := coin.Amount.Sub(f.reserved.AmountOf(coin.Denom)); amount.IsPositive() {
+			available = available.Add(sdk.NewCoin(coin.Denom, amount))
+		}
+	}
+	return available
+}
+
+// lock returns the lock of a denom, creating it on first use
+func (f *Funds) lock(denom string) *sync.Mutex {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lock, ok := f.locks[denom]
+	if !ok {
+		lock = &sync.Mutex{}
+		f.locks[denom] = lock
+	}
+	return lock
+}
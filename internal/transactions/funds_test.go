@@ -0,0 +1,82 @@
+package transactions
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"gotest.tools/assert"
+)
+
+func TestFundsLock(t *testing.T) {
+	d := NewFunds()
+
+	unlock := d.Lock("uosmo", "uatom", "uosmo")
+
+	// A pool without a denom in common is not held up
+	done := make(chan struct{})
+	go func() {
+		d.Lock("uion")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("disjoint denoms were blocked")
+	}
+
+	// A pool sharing a denom waits until the funds are unlocked
+	acquired := make(chan struct{})
+	go func() {
+		d.Lock("uion", "uosmo")()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("shared denom was not locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("shared denom was not unlocked")
+	}
+	assert.Equal(t, len(d.locks), 3)
+}
+
+func TestFundsReserve(t *testing.T) {
+	f := NewFunds()
+	balances := sdk.NewCoins(sdk.NewInt64Coin("uatom", 100), sdk.NewInt64Coin("uosmo", 50))
+
+	unlock := f.Lock("uatom", "uosmo")
+	release := f.Reserve(sdk.NewCoins(sdk.NewInt64Coin("uatom", 40), sdk.NewInt64Coin("uosmo", 50)))
+	unlock()
+
+	// Another pool plans against what the unconfirmed transaction left
+	assert.Equal(t, f.Available(balances).String(), "60uatom")
+
+	// Releasing waits for a pool that is planning with a shared denom
+	unlock = f.Lock("uosmo")
+	released := make(chan struct{})
+	go func() {
+		release()
+		close(released)
+	}()
+
+	select {
+	case <-released:
+		t.Fatal("funds were released while a pool was planning")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	<-released
+
+	// Releasing again does not return the coins twice
+	release()
+	assert.Equal(t, f.Available(balances).String(), balances.String())
+}
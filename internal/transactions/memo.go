@@ -0,0 +1,37 @@
+package transactions
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// maxMemoLength is the default maximum memo length of the auth module
+const maxMemoLength = 256
+
+// MemoData holds the values available to memo templates, for example
+// "flood/{{.Version}} {{.Strategy}} pool:{{.PoolID}}"
+type MemoData struct {
+	Version  string
+	Strategy string
+	PoolID   uint64
+}
+
+// RenderMemo renders the configured memo as a template
+func RenderMemo(memo string, data MemoData) (string, error) {
+	tmpl, err := template.New("memo").Option("missingkey=error").Parse(memo)
+	if err != nil {
+		return "", fmt.Errorf("parsing memo template: %w", err)
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("rendering memo template: %w", err)
+	}
+
+	if rendered.Len() > maxMemoLength {
+		return "", fmt.Errorf("memo %q is longer than %d characters", rendered.String(), maxMemoLength)
+	}
+
+	return rendered.String(), nil
+}
@@ -0,0 +1,29 @@
+package transactions
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestRenderMemo(t *testing.T) {
+	data := MemoData{Version: "v1.2.0", Strategy: "market_make", PoolID: 1299}
+
+	memo, err := RenderMemo("botbot", data)
+	assert.NilError(t, err)
+	assert.Equal(t, memo, "botbot")
+
+	memo, err = RenderMemo("flood/{{.Version}} {{.Strategy}} pool:{{.PoolID}}", data)
+	assert.NilError(t, err)
+	assert.Equal(t, memo, "flood/v1.2.0 market_make pool:1299")
+
+	_, err = RenderMemo("{{.Unknown}}", data)
+	assert.ErrorContains(t, err, "rendering memo template")
+
+	_, err = RenderMemo("{{.Version", data)
+	assert.ErrorContains(t, err, "parsing memo template")
+
+	_, err = RenderMemo(strings.Repeat("a", 257), data)
+	assert.ErrorContains(t, err, "longer than 256 characters")
+}
@@ -0,0 +1,81 @@
+package transactions
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// SequenceFetcher returns the account number and sequence of the signer as
+// stored on chain
+type SequenceFetcher func() (accountNumber, sequence uint64, err error)
+
+// SequenceManager tracks the account sequence of the signer locally so that
+// consecutive transactions do not depend on the node having caught up with
+// the previous one. The sequence is incremented optimistically once a
+// transaction enters the mempool, and fetched from chain again after Reset.
+type SequenceManager struct {
+	mu            sync.Mutex
+	synced        bool
+	accountNumber uint64
+	sequence      uint64
+}
+
+// NewSequenceManager returns a sequence manager that syncs from chain on
+// first use
+func NewSequenceManager() *SequenceManager {
+	return &SequenceManager{}
+}
+
+// Next returns the account number and sequence for the next transaction,
+// fetching them if the manager is not in sync
+func (m *SequenceManager) Next(fetch SequenceFetcher) (uint64, uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.synced {
+		accountNumber, sequence, err := fetch()
+		if err != nil {
+			return 0, 0, err
+		}
+		m.accountNumber, m.sequence, m.synced = accountNumber, sequence, true
+	}
+
+	return m.accountNumber, m.sequence, nil
+}
+
+// Increment advances the sequence after a transaction was accepted into the
+// mempool
+func (m *SequenceManager) Increment() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sequence++
+}
+
+// Reset forces the sequence to be fetched from chain for the next
+// transaction
+func (m *SequenceManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.synced = false
+}
+
+// IsSequenceMismatch reports whether a transaction failed because it was
+// signed with the wrong account sequence. The mismatch is reported as a
+// CheckTx code by a broadcast and as an error message by a simulation.
+func IsSequenceMismatch(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var txErr *TxError
+	if errors.As(err, &txErr) {
+		return txErr.Codespace == sdkerrors.ErrWrongSequence.Codespace() && txErr.Code == sdkerrors.ErrWrongSequence.ABCICode()
+	}
+
+	return strings.Contains(err.Error(), "account sequence mismatch")
+}
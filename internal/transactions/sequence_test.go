@@ -0,0 +1,62 @@
+package transactions
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSequenceManager(t *testing.T) {
+	fetches := 0
+	fetch := func() (uint64, uint64, error) {
+		fetches++
+		return 7, 10, nil
+	}
+
+	m := NewSequenceManager()
+
+	accountNumber, sequence, err := m.Next(fetch)
+	assert.NilError(t, err)
+	assert.Equal(t, accountNumber, uint64(7))
+	assert.Equal(t, sequence, uint64(10))
+
+	// The sequence is tracked locally once synced
+	m.Increment()
+	_, sequence, err = m.Next(fetch)
+	assert.NilError(t, err)
+	assert.Equal(t, sequence, uint64(11))
+	assert.Equal(t, fetches, 1)
+
+	// A reset syncs from chain again
+	m.Reset()
+	_, sequence, err = m.Next(fetch)
+	assert.NilError(t, err)
+	assert.Equal(t, sequence, uint64(10))
+	assert.Equal(t, fetches, 2)
+}
+
+func TestSequenceManagerFetchError(t *testing.T) {
+	m := NewSequenceManager()
+
+	_, _, err := m.Next(func() (uint64, uint64, error) {
+		return 0, 0, errors.New("unavailable")
+	})
+	assert.ErrorContains(t, err, "unavailable")
+
+	_, sequence, err := m.Next(func() (uint64, uint64, error) {
+		return 1, 3, nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, sequence, uint64(3))
+}
+
+func TestIsSequenceMismatch(t *testing.T) {
+	assert.Assert(t, IsSequenceMismatch(&TxError{Codespace: "sdk", Code: 32}))
+	assert.Assert(t, IsSequenceMismatch(fmt.Errorf("broadcasting: %w", &TxError{Codespace: "sdk", Code: 32})))
+	assert.Assert(t, IsSequenceMismatch(errors.New("account sequence mismatch, expected 11, got 10: incorrect account sequence")))
+	assert.Assert(t, !IsSequenceMismatch(&TxError{Codespace: "sdk", Code: 5}))
+	assert.Assert(t, !IsSequenceMismatch(&TxError{Codespace: "concentratedliquidity", Code: 32}))
+	assert.Assert(t, !IsSequenceMismatch(nil))
+}
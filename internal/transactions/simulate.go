@@ -0,0 +1,33 @@
+package transactions
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/ignite/cli/ignite/pkg/cosmosclient"
+)
+
+// Simulate runs the messages against the node as a transaction from the
+// signer. The transaction is neither signed nor broadcast.
+func (b *Broadcaster) Simulate(client *cosmosclient.Client, msgs ...sdk.Msg) (*txtypes.SimulateResponse, error) {
+	clientCtx, err := b.clientContext(client)
+	if err != nil {
+		return nil, err
+	}
+
+	txf, err := client.TxFactory.
+		WithMemo(b.memo).
+		Prepare(clientCtx)
+	if err != nil {
+		return nil, fmt.Errorf("preparing tx factory: %w", err)
+	}
+
+	simRes, _, err := tx.CalculateGas(clientCtx, txf, msgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return simRes, nil
+}
@@ -2,14 +2,17 @@ package types
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 
 	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
-	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/ignite/cli/ignite/pkg/cosmosclient"
+	"github.com/osmosis-labs/osmosis/osmomath"
 	clquery "github.com/osmosis-labs/osmosis/v21/x/concentrated-liquidity/client/queryproto"
 	pmquery "github.com/osmosis-labs/osmosis/v21/x/poolmanager/client/queryproto"
+	txfeestypes "github.com/osmosis-labs/osmosis/v21/x/txfees/types"
 	"google.golang.org/grpc"
 )
 
@@ -31,26 +34,72 @@ type PowerPool struct {
 	ContractAddress string `toml:"contract_address"`
 }
 
+// PoolConfig is a power pool managed by the bot together with its strategy,
+// position parameters and the events that trigger its rebalances
+type PoolConfig struct {
+	PoolId          uint64   `toml:"pool_id"`
+	BaseAsset       string   `toml:"base_asset"`
+	QuoteAsset      string   `toml:"quote_asset"`
+	ContractAddress string   `toml:"contract_address"`
+	Strategy        string   `toml:"strategy"`
+	EventQueries    []string `toml:"event_queries"`
+	TriggerPools    []uint64 `toml:"trigger_pools"`
+	Position        Position `toml:"position"`
+}
+
 type Position struct {
-	DefaultToken0Amount int64  `toml:"default_token_0_amount"`
-	DefaultToken1Amount int64  `toml:"default_token_1_amount"`
-	Spread              string `toml:"spread"`
-	LpSpread            string `toml:"lp_spread"`
+	DefaultToken0Amount int64   `toml:"default_token_0_amount"`
+	DefaultToken1Amount int64   `toml:"default_token_1_amount"`
+	Spread              string  `toml:"spread"`
+	LpSpread            string  `toml:"lp_spread"`
+	PremiumThreshold    float64 `toml:"premium_threshold"`
+	PremiumHysteresis   float64 `toml:"premium_hysteresis"`
+	Ranges              int     `toml:"ranges"`
+	UseWalletBalance    bool    `toml:"use_wallet_balance"`
+	SlippageTolerance   string  `toml:"slippage_tolerance"`
 }
 
 type Config struct {
-	AddressPrefix     string     `toml:"address_prefix"`
-	Fees              string     `toml:"fees"`
-	GasAdjustment     float64    `toml:"gas_adjustment"`
-	Gas               string     `toml:"gas"`
-	GRPCServerAddress string     `toml:"grpc_server_address"`
-	Key               SigningKey `toml:"key"`
-	Memo              string     `toml:"memo"`
-	PowerPool         PowerPool  `toml:"power_pool"`
-	RPCServerAddress  string     `toml:"rpc_server_address"`
-	WebsocketPath     string     `toml:"websocket_path"`
-	SignerAccount     string     `toml:"signer_account"`
-	Position          Position   `toml:"position"`
+	AddressPrefix          string        `toml:"address_prefix"`
+	Fees                   string        `toml:"fees"`
+	FeeMode                string        `toml:"fee_mode"`
+	MaxFee                 string        `toml:"max_fee"`
+	BaseFeeMultiplier      float64       `toml:"base_fee_multiplier"`
+	GasAdjustment          float64       `toml:"gas_adjustment"`
+	Gas                    string        `toml:"gas"`
+	GRPCServerAddress      string        `toml:"grpc_server_address"`
+	GRPCServerAddresses    []string      `toml:"grpc_server_addresses"`
+	Key                    SigningKey    `toml:"key"`
+	Memo                   string        `toml:"memo"`
+	PowerPool              PowerPool     `toml:"power_pool"`
+	RPCServerAddress       string        `toml:"rpc_server_address"`
+	RPCServerAddresses     []string      `toml:"rpc_server_addresses"`
+	MaxHeightLag           int64         `toml:"max_height_lag"`
+	MaxLatency             time.Duration `toml:"max_latency"`
+	HealthCheckInterval    time.Duration `toml:"health_check_interval"`
+	WebsocketPath          string        `toml:"websocket_path"`
+	WebsocketStaleTimeout  time.Duration `toml:"websocket_stale_timeout"`
+	MaxSubscriptions       int           `toml:"max_subscriptions_per_client"`
+	ReconnectMaxBackoff    time.Duration `toml:"reconnect_max_backoff"`
+	CoalesceWindow         time.Duration `toml:"coalesce_window"`
+	RebalanceInterval      time.Duration `toml:"rebalance_interval"`
+	RebalanceBlocks        int64         `toml:"rebalance_interval_blocks"`
+	SignerAccount          string        `toml:"signer_account"`
+	Strategy               string        `toml:"strategy"`
+	EventQueries           []string      `toml:"event_queries"`
+	TriggerPools           []uint64      `toml:"trigger_pools"`
+	RetryAttempts          int           `toml:"retry_attempts"`
+	RetryBackoff           time.Duration `toml:"retry_backoff"`
+	MaxConsecutiveFailures int           `toml:"max_consecutive_failures"`
+	ShutdownTimeout        time.Duration `toml:"shutdown_timeout"`
+	WithdrawOnShutdown     bool          `toml:"withdraw_on_shutdown"`
+	DryRun                 bool          `toml:"dry_run"`
+	ConfirmationTimeout    time.Duration `toml:"confirmation_timeout"`
+	HTTPAddress            string        `toml:"http_address"`
+	HealthStaleTimeout     time.Duration `toml:"health_stale_timeout"`
+	MinSignerBalance       string        `toml:"min_signer_balance"`
+	Position               Position      `toml:"position"`
+	Pools                  []PoolConfig  `toml:"pools"`
 }
 
 // getVaultResponse represents the response structure for querying information about a vault.
@@ -87,14 +136,27 @@ type Pool struct {
 	QuoteDenom string `json:"quote_denom"`
 }
 
+// ConcentratedPool holds the parameters and current state of a concentrated
+// liquidity pool as read from chain.
+type ConcentratedPool struct {
+	ID               uint64
+	Token0           string
+	Token1           string
+	TickSpacing      int64
+	SpreadFactor     string
+	CurrentTick      int64
+	CurrentSqrtPrice osmomath.BigDec
+}
+
 type BlockchainClients struct {
-	CosmosClient    *cosmosclient.Client
-	WebsocketClient *rpchttp.HTTP
-	WasmClient      wasmtypes.QueryClient
-	GRPCClient      *grpc.ClientConn
-	PMClient        pmquery.QueryClient
-	CLClient        clquery.QueryClient
-	Config          *Config
+	CosmosClient *cosmosclient.Client
+	WasmClient   wasmtypes.QueryClient
+	GRPCClient   *grpc.ClientConn
+	PMClient     pmquery.QueryClient
+	CLClient     clquery.QueryClient
+	BankClient   banktypes.QueryClient
+	TxFeesClient txfeestypes.QueryClient
+	Config       *Config
 }
 
 type LoggerContext struct {